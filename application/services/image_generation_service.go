@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -485,26 +486,25 @@ func (s *ImageGenerationService) runComfyUIGeneration(imageGenID uint, imageGen
 		return
 	}
 	params := &comfyui.Params{
-		Prompt: imageGen.Prompt,
-		Width:  1920,
-		Height: 1080,
-		Steps:  25,
-		CFG:    1,
-		Seed:   0,
+		Prompt:        imageGen.Prompt,
+		Width:         1920,
+		Height:        1080,
+		SamplerConfig: comfyui.SamplerConfig{Steps: 25, CFG: 1},
+		Seed:          0,
 	}
 	if s.config.ComfyUI.BaiduTranslateAppID != "" && s.config.ComfyUI.BaiduTranslateAppKey != "" {
 		params.BaiduTranslateAppID = s.config.ComfyUI.BaiduTranslateAppID
 		params.BaiduTranslateAppKey = s.config.ComfyUI.BaiduTranslateAppKey
 	}
-	client := &comfyui.Client{BaseURL: baseURL, HTTP: nil}
-	imageURL, err := client.Generate(params)
+	client := &comfyui.Client{BaseURL: baseURL, HTTP: nil, UseWebSocketIfAvailable: true}
+	result, err := client.Generate(context.Background(), params)
 	if err != nil {
 		s.log.Errorw("ComfyUI generation failed", "id", imageGenID, "error", err)
 		s.updateImageGenError(imageGenID, "ComfyUI 生成失败: "+err.Error())
 		return
 	}
 	s.completeImageGeneration(imageGenID, &image.ImageResult{
-		ImageURL:  imageURL,
+		ImageURL:  result.ImageURL,
 		Completed: true,
 		Width:     1920,
 		Height:    1080,
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -395,24 +396,23 @@ func (s *StoryboardCompositionService) GenerateSceneImage(req *GenerateSceneImag
 		if baseURL == "" {
 			return nil, fmt.Errorf("ComfyUI 未配置：请在设置中填写 ComfyUI 服务器地址，或 config 中配置 comfyui.base_url")
 		}
-		client := &comfyui.Client{BaseURL: baseURL, HTTP: nil}
+		client := &comfyui.Client{BaseURL: baseURL, HTTP: nil, UseWebSocketIfAvailable: true}
 		params := &comfyui.Params{
-			Prompt: prompt,
-			Width:  1920,
-			Height: 1080,
-			Steps:  25,
-			CFG:    1,
-			Seed:   0,
+			Prompt:        prompt,
+			Width:         1920,
+			Height:        1080,
+			SamplerConfig: comfyui.SamplerConfig{Steps: 25, CFG: 1},
+			Seed:          0,
 		}
 		if s.config != nil {
 			params.BaiduTranslateAppID = s.config.ComfyUI.BaiduTranslateAppID
 			params.BaiduTranslateAppKey = s.config.ComfyUI.BaiduTranslateAppKey
 		}
-		imageURL, err := client.Generate(params)
+		result, err := client.Generate(context.Background(), params)
 		if err != nil {
 			return nil, fmt.Errorf("ComfyUI 生成失败: %w", err)
 		}
-		imageGen, err := s.imageGen.CreateImageFromURL(req.SceneID, scene.DramaID, prompt, imageURL)
+		imageGen, err := s.imageGen.CreateImageFromURL(req.SceneID, scene.DramaID, prompt, result.ImageURL)
 		if err != nil {
 			return nil, err
 		}
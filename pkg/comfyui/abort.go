@@ -0,0 +1,46 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAborted Client.Abort 已被调用
+var ErrAborted = errors.New("comfyui client aborted")
+
+// Abort 终止该 Client 上所有进行中的生成（取消 HTTP 请求、关闭 WebSocket、停止轮询），
+// 之后的 Generate 调用直接返回 ErrAborted；与 ctx 取消不同，作用于所有并发调用
+func (c *Client) Abort() error {
+	c.abortContext()
+	c.aborted.Store(true)
+	c.abortCancel()
+	return nil
+}
+
+func (c *Client) abortContext() context.Context {
+	c.abortOnce.Do(func() {
+		c.abortCtx, c.abortCancel = context.WithCancel(context.Background())
+	})
+	return c.abortCtx
+}
+
+// withAbort 返回在 Abort 时一并取消的子 context
+func (c *Client) withAbort(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if c.aborted.Load() {
+		cancel(ErrAborted)
+	}
+	stop := context.AfterFunc(c.abortContext(), func() { cancel(ErrAborted) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// abortErr Abort 之后把 context.Canceled 等派生错误统一为 ErrAborted
+func (c *Client) abortErr(err error) error {
+	if err != nil && c.aborted.Load() {
+		return ErrAborted
+	}
+	return err
+}
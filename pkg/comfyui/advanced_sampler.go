@@ -0,0 +1,156 @@
+package comfyui
+
+import "errors"
+
+// ErrAdvancedSamplerConflict AdvancedSampler 使用自定义 CFGGuider，无法接入需要改写采样器模型 / 条件的功能
+var ErrAdvancedSamplerConflict = errors.New("comfyui advanced sampler with custom guider cannot be combined with model or conditioning patches")
+
+// AdvancedSamplerConfig SamplerCustomAdvanced 的四路输入；字段为空时使用默认节点：
+// RandomNoise(50，种子为 Params.Seed)、BasicGuider(51)、KSamplerSelect(52)、BasicScheduler(53，调度器为 Params.Scheduler)。
+// BasicScheduler 按模型自身的 sigma 范围取值（Flux 为流匹配的 [1, 0]），需要 Karras 调度时把 Params.Scheduler 设为 karras；
+// 填写 NodeRef 则直接连到调用方自行加入工作流的节点（例如自定义噪声或 sigmas 节点）。
+// 自定义 CFGGuider 时无法再改写模型与条件输入，IP-Adapter、ControlNet、MergeModels 会返回 ErrAdvancedSamplerConflict
+type AdvancedSamplerConfig struct {
+	NoiseSeed NodeRef `json:"noise_seed" yaml:"noise_seed"`
+	CFGGuider NodeRef `json:"cfg_guider" yaml:"cfg_guider"`
+	Sampler   NodeRef `json:"sampler" yaml:"sampler"`
+	Sigmas    NodeRef `json:"sigmas" yaml:"sigmas"`
+}
+
+// applyAdvancedSampler 把节点 15 从 KSampler 换成 SamplerCustomAdvanced，VAEDecode 仍读取 15 的第 0 个输出
+func applyAdvancedSampler(workflow map[string]interface{}, p *Params) {
+	cfg := p.AdvancedSampler
+	// 沿用 KSampler 当前的模型与正向条件（可能已串联 LoRA）
+	model := samplerInputs(workflow)["model"]
+	positive := samplerInputs(workflow)["positive"]
+	noise := cfg.NoiseSeed
+	if noise.NodeID == "" {
+		workflow["50"] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"noise_seed": p.Seed},
+			"class_type": "RandomNoise",
+		}
+		noise = NodeRef{NodeID: "50"}
+	}
+	guider := cfg.CFGGuider
+	if guider.NodeID == "" {
+		workflow["51"] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"model": model, "conditioning": positive},
+			"class_type": "BasicGuider",
+		}
+		guider = NodeRef{NodeID: "51"}
+	}
+	sampler := cfg.Sampler
+	if sampler.NodeID == "" {
+		workflow["52"] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"sampler_name": p.Sampler},
+			"class_type": "KSamplerSelect",
+		}
+		sampler = NodeRef{NodeID: "52"}
+	}
+	sigmas := cfg.Sigmas
+	if sigmas.NodeID == "" {
+		workflow["53"] = map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"model": model, "scheduler": p.Scheduler, "steps": p.Steps, "denoise": p.Denoise,
+			},
+			"class_type": "BasicScheduler",
+		}
+		sigmas = NodeRef{NodeID: "53"}
+	}
+	workflow["15"] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"noise":        noise.wire(),
+			"guider":       guider.wire(),
+			"sampler":      sampler.wire(),
+			"sigmas":       sigmas.wire(),
+			"latent_image": []interface{}{"20", 0},
+		},
+		"class_type": "SamplerCustomAdvanced",
+	}
+}
+
+// 以下函数统一读写节点 15 的模型与条件输入：KSampler 直接在节点 15 上，SamplerCustomAdvanced 则在默认的
+// BasicGuider(51)（模型、正向条件）与 BasicScheduler(53)（模型）上；补丁类功能（IP-Adapter、ControlNet、模型混合）都经由它们改写
+
+// advancedGuider 节点 15 为 SamplerCustomAdvanced 时返回默认 BasicGuider(51) 的输入；ok 为 false 表示节点 15 是 KSampler
+func advancedGuider(workflow map[string]interface{}) (inputs map[string]interface{}, ok bool, err error) {
+	node := workflow["15"].(map[string]interface{})
+	if node["class_type"] != "SamplerCustomAdvanced" {
+		return nil, false, nil
+	}
+	guider, _ := node["inputs"].(map[string]interface{})["guider"].([]interface{})
+	if len(guider) == 0 || guider[0] != "51" {
+		return nil, true, ErrAdvancedSamplerConflict
+	}
+	return workflow["51"].(map[string]interface{})["inputs"].(map[string]interface{}), true, nil
+}
+
+func samplerInputs(workflow map[string]interface{}) map[string]interface{} {
+	return workflow["15"].(map[string]interface{})["inputs"].(map[string]interface{})
+}
+
+// samplerModel 采样器当前使用的模型（可能已串联 LoRA）
+func samplerModel(workflow map[string]interface{}) (interface{}, error) {
+	guider, advanced, err := advancedGuider(workflow)
+	if err != nil {
+		return nil, err
+	}
+	if advanced {
+		return guider["model"], nil
+	}
+	model, ok := samplerInputs(workflow)["model"]
+	if !ok {
+		model = []interface{}{"17", 0}
+	}
+	return model, nil
+}
+
+// setSamplerModel 让采样器改用 model；SamplerCustomAdvanced 同时更新 BasicScheduler(53) 的模型
+func setSamplerModel(workflow map[string]interface{}, model interface{}) error {
+	guider, advanced, err := advancedGuider(workflow)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		samplerInputs(workflow)["model"] = model
+		return nil
+	}
+	guider["model"] = model
+	if scheduler, ok := workflow["53"].(map[string]interface{}); ok && scheduler["class_type"] == "BasicScheduler" {
+		scheduler["inputs"].(map[string]interface{})["model"] = model
+	}
+	return nil
+}
+
+// samplerConditioning 采样器当前的正负条件；BasicGuider 没有负向条件，取工作流中的 ConditioningZeroOut(4) 或负向提示词(25)
+func samplerConditioning(workflow map[string]interface{}) (positive, negative interface{}, err error) {
+	guider, advanced, err := advancedGuider(workflow)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !advanced {
+		inputs := samplerInputs(workflow)
+		return inputs["positive"], inputs["negative"], nil
+	}
+	negative = []interface{}{"4", 0}
+	if _, ok := workflow[negativePromptNodeID]; ok {
+		negative = []interface{}{negativePromptNodeID, 0}
+	}
+	return guider["conditioning"], negative, nil
+}
+
+// setSamplerConditioning 改写采样器的正负条件；BasicGuider 只使用正向条件
+func setSamplerConditioning(workflow map[string]interface{}, positive, negative interface{}) error {
+	guider, advanced, err := advancedGuider(workflow)
+	if err != nil {
+		return err
+	}
+	if advanced {
+		guider["conditioning"] = positive
+		return nil
+	}
+	inputs := samplerInputs(workflow)
+	inputs["positive"] = positive
+	inputs["negative"] = negative
+	return nil
+}
@@ -0,0 +1,69 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AltTextBLIPModel GenerateAltText 使用的 BLIP 模型
+var AltTextBLIPModel = "Salesforce/blip-image-captioning-base"
+
+// GenerateAltText 用 BLIP 为图片生成英文描述作为 alt 文本：
+// LoadImage → BLIP Model Loader → BLIP Analyze Image（WAS Node Suite）→ ShowText|pysssss，从 history 输出读取文本
+func (c *Client) GenerateAltText(ctx context.Context, imageURL string) (string, error) {
+	name, err := c.uploadFromURL(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	model := b.AddNode("BLIP Model Loader", map[string]interface{}{
+		"blip_model": AltTextBLIPModel,
+		"vqa_model":  "Salesforce/blip-vqa-base",
+		"device":     "cuda",
+	})
+	caption := b.AddNode("BLIP Analyze Image", map[string]interface{}{
+		"images":     b.Out(load, 0),
+		"blip_model": b.Out(model, 0),
+		"mode":       "caption",
+		"question":   "What does the background consist of?",
+		"min_length": 24,
+		"max_length": 64,
+		"num_beams":  5,
+	})
+	show := b.AddNode("ShowText|pysssss", map[string]interface{}{"text": b.Out(caption, 0)})
+
+	promptID, err := c.submitWorkflow(ctx, b.Build())
+	if err != nil {
+		return "", err
+	}
+	entry, err := c.waitForEntry(ctx, promptID)
+	if err != nil {
+		return "", err
+	}
+	text, ok := historyText(entry.Raw, show)
+	if !ok {
+		return "", fmt.Errorf("%w: prompt %s has no caption", ErrNoOutput, promptID)
+	}
+	return text, nil
+}
+
+// historyText 读取 outputs.<nodeID>.text 中第一个非空文本
+func historyText(raw json.RawMessage, nodeID string) (string, bool) {
+	var history struct {
+		Outputs map[string]struct {
+			Text []string `json:"text"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return "", false
+	}
+	for _, text := range history.Outputs[nodeID].Text {
+		if text = strings.TrimSpace(text); text != "" {
+			return text, true
+		}
+	}
+	return "", false
+}
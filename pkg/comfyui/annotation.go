@@ -0,0 +1,129 @@
+package comfyui
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoAnnotationStore 未配置 Client.Annotations
+var ErrNoAnnotationStore = errors.New("comfyui annotation store is not configured")
+
+// Annotation 图片标注，如场景 ID、演员名、审核状态
+type Annotation struct {
+	Tag       string    `json:"tag"`
+	Value     string    `json:"value"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnnotationStore 标注存储后端
+type AnnotationStore interface {
+	Add(ctx context.Context, imageURL string, a Annotation) error
+	List(ctx context.Context, imageURL string) ([]Annotation, error)
+}
+
+// Annotate 为生成的图片添加标注，CreatedAt 为空时取当前时间
+func (c *Client) Annotate(ctx context.Context, imageURL string, annotation Annotation) error {
+	if c.Annotations == nil {
+		return ErrNoAnnotationStore
+	}
+	if annotation.Tag == "" {
+		return fmt.Errorf("comfyui annotation tag is required")
+	}
+	if annotation.CreatedAt.IsZero() {
+		annotation.CreatedAt = time.Now()
+	}
+	return c.Annotations.Add(ctx, imageURL, annotation)
+}
+
+// GetAnnotations 按添加时间顺序返回图片的全部标注
+func (c *Client) GetAnnotations(ctx context.Context, imageURL string) ([]Annotation, error) {
+	if c.Annotations == nil {
+		return nil, ErrNoAnnotationStore
+	}
+	return c.Annotations.List(ctx, imageURL)
+}
+
+// MemoryAnnotationStore 进程内标注存储，适合测试与单实例部署
+type MemoryAnnotationStore struct {
+	mu   sync.RWMutex
+	data map[string][]Annotation
+}
+
+func NewMemoryAnnotationStore() *MemoryAnnotationStore {
+	return &MemoryAnnotationStore{data: make(map[string][]Annotation)}
+}
+
+func (s *MemoryAnnotationStore) Add(ctx context.Context, imageURL string, a Annotation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[imageURL] = append(s.data[imageURL], a)
+	return nil
+}
+
+func (s *MemoryAnnotationStore) List(ctx context.Context, imageURL string) ([]Annotation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Annotation(nil), s.data[imageURL]...), nil
+}
+
+// PostgresAnnotationStore 使用 PostgreSQL 保存标注；db 由调用方以任意 PostgreSQL 驱动（pgx、lib/pq）打开
+type PostgresAnnotationStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresAnnotationStore table 为空时使用 comfyui_annotations
+func NewPostgresAnnotationStore(db *sql.DB, table string) *PostgresAnnotationStore {
+	if table == "" {
+		table = "comfyui_annotations"
+	}
+	return &PostgresAnnotationStore{db: db, table: table}
+}
+
+// EnsureSchema 创建标注表及索引（已存在则跳过）
+func (s *PostgresAnnotationStore) EnsureSchema(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id BIGSERIAL PRIMARY KEY,
+	image_url TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	value TEXT NOT NULL DEFAULT '',
+	author TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS %[1]s_image_url_idx ON %[1]s (image_url);`, s.table))
+	return err
+}
+
+func (s *PostgresAnnotationStore) Add(ctx context.Context, imageURL string, a Annotation) error {
+	_, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (image_url, tag, value, author, created_at) VALUES ($1, $2, $3, $4, $5)`, s.table),
+		imageURL, a.Tag, a.Value, a.Author, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("comfyui insert annotation: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresAnnotationStore) List(ctx context.Context, imageURL string) ([]Annotation, error) {
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT tag, value, author, created_at FROM %s WHERE image_url = $1 ORDER BY created_at, id`, s.table),
+		imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui query annotations: %w", err)
+	}
+	defer rows.Close()
+	var list []Annotation
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.Tag, &a.Value, &a.Author, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
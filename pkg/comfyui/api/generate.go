@@ -0,0 +1,9 @@
+// Package api 由 comfyui_openapi.yaml 生成的 ComfyUI 请求/响应类型与 HTTP 客户端。
+//
+// 修改 comfyui_openapi.yaml 后执行 go generate ./pkg/comfyui/api 重新生成 types.gen.go 与 client.gen.go，生成结果随代码提交。
+// pkg/comfyui 通过 Client.apiClient 调用这里的 Client，请求仍经 comfyui.Client 的 HTTP 处理（鉴权、重连、429 重试、缓存）发出；
+// SystemStats、DeviceStats、HistoryImage 是这里类型的别名，HistoryEntry 需要解析 status.messages 二元组与视频输出，仍为手写结构体。
+package api
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -generate types -package api -o types.gen.go comfyui_openapi.yaml
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -generate client -package api -o client.gen.go comfyui_openapi.yaml
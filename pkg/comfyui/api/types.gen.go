@@ -0,0 +1,154 @@
+// Package api provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package api
+
+import (
+	"encoding/json"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// Defines values for GetViewParamsType.
+const (
+	Input  GetViewParamsType = "input"
+	Output GetViewParamsType = "output"
+	Temp   GetViewParamsType = "temp"
+)
+
+// DeviceStats defines model for DeviceStats.
+type DeviceStats struct {
+	Index     int    `json:"index,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type,omitempty"`
+	VRAMFree  int64  `json:"vram_free,omitempty"`
+	VRAMTotal int64  `json:"vram_total,omitempty"`
+}
+
+// HistoryEntry defines model for HistoryEntry.
+type HistoryEntry struct {
+	Outputs *map[string]struct {
+		Images *[]HistoryImage `json:"images,omitempty"`
+	} `json:"outputs,omitempty"`
+
+	// Prompt [number, prompt_id, workflow, extra_data, output_node_ids]
+	Prompt *[]interface{} `json:"prompt,omitempty"`
+	Status *struct {
+		Completed *bool `json:"completed,omitempty"`
+
+		// Messages [type, data] 二元组，如 ["execution_error", {...}]
+		Messages  *[][]interface{} `json:"messages,omitempty"`
+		StatusStr *string          `json:"status_str,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// HistoryImage defines model for HistoryImage.
+type HistoryImage struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// PromptError defines model for PromptError.
+type PromptError struct {
+	Error      map[string]interface{} `json:"error,omitempty"`
+	NodeErrors map[string]interface{} `json:"node_errors,omitempty"`
+}
+
+// PromptRequest defines model for PromptRequest.
+type PromptRequest struct {
+	ClientID string `json:"client_id,omitempty"`
+
+	// Prompt 节点 ID -> {class_type, inputs}
+	Prompt json.RawMessage `json:"prompt"`
+}
+
+// PromptResponse defines model for PromptResponse.
+type PromptResponse struct {
+	NodeErrors map[string]interface{} `json:"node_errors,omitempty"`
+	Number     int                    `json:"number,omitempty"`
+	PromptID   string                 `json:"prompt_id,omitempty"`
+}
+
+// Queue defines model for Queue.
+type Queue struct {
+	QueuePending []json.RawMessage `json:"queue_pending,omitempty"`
+
+	// QueueRunning [number, prompt_id, prompt, extra_data, outputs_to_execute]
+	QueueRunning []json.RawMessage `json:"queue_running,omitempty"`
+}
+
+// SystemStats defines model for SystemStats.
+type SystemStats struct {
+	Devices []DeviceStats `json:"devices,omitempty"`
+	System  struct {
+		// ComfyUIVersion 旧版 ComfyUI 不返回
+		ComfyUIVersion string `json:"comfyui_version,omitempty"`
+		OS             string `json:"os,omitempty"`
+		PythonVersion  string `json:"python_version,omitempty"`
+		PytorchVersion string `json:"pytorch_version,omitempty"`
+	} `json:"system,omitempty"`
+}
+
+// UploadResponse defines model for UploadResponse.
+type UploadResponse struct {
+	Name      string `json:"name"`
+	Subfolder string `json:"subfolder,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// PostFreeJSONBody defines parameters for PostFree.
+type PostFreeJSONBody struct {
+	FreeMemory   bool `json:"free_memory,omitempty"`
+	UnloadModels bool `json:"unload_models,omitempty"`
+}
+
+// GetHistoryParams defines parameters for GetHistory.
+type GetHistoryParams struct {
+	// MaxItems 只返回最近的 N 条
+	MaxItems *int `form:"max_items,omitempty" json:"max_items,omitempty"`
+}
+
+// PostInterruptJSONBody defines parameters for PostInterrupt.
+type PostInterruptJSONBody struct {
+	PromptID string `json:"prompt_id,omitempty"`
+}
+
+// PostQueueJSONBody defines parameters for PostQueue.
+type PostQueueJSONBody struct {
+	Clear  bool     `json:"clear,omitempty"`
+	Delete []string `json:"delete,omitempty"`
+}
+
+// PostUploadImageMultipartBody defines parameters for PostUploadImage.
+type PostUploadImageMultipartBody struct {
+	Image     openapi_types.File `json:"image"`
+	Overwrite *string            `json:"overwrite,omitempty"`
+	Subfolder *string            `json:"subfolder,omitempty"`
+	Type      *string            `json:"type,omitempty"`
+}
+
+// GetViewParams defines parameters for GetView.
+type GetViewParams struct {
+	Filename  string             `form:"filename" json:"filename"`
+	Subfolder *string            `form:"subfolder,omitempty" json:"subfolder,omitempty"`
+	Type      *GetViewParamsType `form:"type,omitempty" json:"type,omitempty"`
+}
+
+// GetViewParamsType defines parameters for GetView.
+type GetViewParamsType string
+
+// PostFreeJSONRequestBody defines body for PostFree for application/json ContentType.
+type PostFreeJSONRequestBody PostFreeJSONBody
+
+// PostInterruptJSONRequestBody defines body for PostInterrupt for application/json ContentType.
+type PostInterruptJSONRequestBody PostInterruptJSONBody
+
+// PostPromptJSONRequestBody defines body for PostPrompt for application/json ContentType.
+type PostPromptJSONRequestBody = PromptRequest
+
+// PostQueueJSONRequestBody defines body for PostQueue for application/json ContentType.
+type PostQueueJSONRequestBody PostQueueJSONBody
+
+// PostUploadImageMultipartRequestBody defines body for PostUploadImage for multipart/form-data ContentType.
+type PostUploadImageMultipartRequestBody PostUploadImageMultipartBody
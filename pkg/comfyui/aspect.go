@@ -0,0 +1,38 @@
+package comfyui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+)
+
+// aspectRatioTolerance 实际宽高比与请求宽高比允许的相对偏差
+const aspectRatioTolerance = 0.02
+
+// ErrAspectRatioMismatch 输出图片的宽高比与 Params.Width / Height 相差超过 2%（如模型覆盖了 latent 尺寸）
+type ErrAspectRatioMismatch struct {
+	Expected image.Point
+	Got      image.Point
+}
+
+func (e ErrAspectRatioMismatch) Error() string {
+	return fmt.Sprintf("comfyui output aspect ratio mismatch: want %dx%d, got %dx%d", e.Expected.X, e.Expected.Y, e.Got.X, e.Got.Y)
+}
+
+// checkAspectRatio 只解码图片头部取尺寸
+func checkAspectRatio(data []byte, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("comfyui decode output image: %w", err)
+	}
+	want := float64(width) / float64(height)
+	got := float64(cfg.Width) / float64(cfg.Height)
+	if cfg.Height == 0 || math.Abs(got-want)/want > aspectRatioTolerance {
+		return ErrAspectRatioMismatch{Expected: image.Pt(width, height), Got: image.Pt(cfg.Width, cfg.Height)}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GenerateBatch 以 Client.Concurrency 为并发上限批量生成，结果与 params 顺序一致；
+// 失败项对应的结果为 nil，所有失败合并为一个 error 返回
+func (c *Client) GenerateBatch(ctx context.Context, params []*Params) ([]*GenerateResult, error) {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	results := make([]*GenerateResult, len(params))
+	errs := make([]error, len(params))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, p := range params {
+		if i > 0 && c.BatchSubmitDelay > 0 {
+			select {
+			case <-time.After(c.BatchSubmitDelay):
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(params); j++ {
+				errs[j] = fmt.Errorf("comfyui batch item %d: %w", j, err)
+			}
+			break
+		}
+		wg.Add(1)
+		go func(i int, p *Params) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res, err := c.generateResult(ctx, p)
+			if err != nil {
+				errs[i] = fmt.Errorf("comfyui batch item %d: %w", i, err)
+				return
+			}
+			results[i] = res
+		}(i, p)
+	}
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
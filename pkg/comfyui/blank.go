@@ -0,0 +1,48 @@
+package comfyui
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ErrBlankOutput 重试后输出仍是空白图
+var ErrBlankOutput = errors.New("comfyui generated a blank image")
+
+const defaultBlankThreshold = 0.99
+
+func (c *Client) blankThreshold() float64 {
+	if c.BlankThreshold > 0 {
+		return c.BlankThreshold
+	}
+	return defaultBlankThreshold
+}
+
+// isBlankImage 按 32×32 网格采样，统计接近纯黑或纯白的像素占比；无法解码时不视为空白
+func isBlankImage(data []byte, threshold float64) bool {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	const grid = 32
+	const lo, hi = 10 << 8, 245 << 8 // 16 位颜色分量
+	b := img.Bounds()
+	if b.Empty() {
+		return true
+	}
+	total, blank := 0, 0
+	for gy := 0; gy < grid; gy++ {
+		for gx := 0; gx < grid; gx++ {
+			x := b.Min.X + gx*b.Dx()/grid
+			y := b.Min.Y + gy*b.Dy()/grid
+			r, g, bl, _ := img.At(x, y).RGBA()
+			total++
+			if (r < lo && g < lo && bl < lo) || (r > hi && g > hi && bl > hi) {
+				blank++
+			}
+		}
+	}
+	return float64(blank)/float64(total) > threshold
+}
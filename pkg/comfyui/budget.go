@@ -0,0 +1,81 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded 当前周期内 GPU 秒数预算已用完
+var ErrBudgetExceeded = errors.New("comfyui generation budget exceeded")
+
+// BudgetTracker 按周期限制 GPU 秒数消耗（每次 Generate 提交前按预估时长扣减）
+type BudgetTracker interface {
+	Consume(ctx context.Context, estimatedSeconds float64) error
+	Remaining() float64
+}
+
+// MemoryBudget 进程内的 BudgetTracker 实现，每个周期最多消耗 Limit 秒
+type MemoryBudget struct {
+	mu          sync.Mutex
+	limit       float64
+	used        float64
+	period      time.Duration
+	periodStart time.Time
+}
+
+// NewMemoryBudget 创建每 period 最多消耗 limitSeconds GPU 秒的预算
+func NewMemoryBudget(limitSeconds float64, period time.Duration) *MemoryBudget {
+	return &MemoryBudget{limit: limitSeconds, period: period, periodStart: time.Now()}
+}
+
+// Consume 扣减预估秒数；周期到期后自动清零，余额不足时返回 ErrBudgetExceeded
+func (b *MemoryBudget) Consume(ctx context.Context, estimatedSeconds float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollLocked()
+	if b.used+estimatedSeconds > b.limit {
+		return ErrBudgetExceeded
+	}
+	b.used += estimatedSeconds
+	return nil
+}
+
+// Remaining 当前周期剩余的 GPU 秒数
+func (b *MemoryBudget) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollLocked()
+	if b.used >= b.limit {
+		return 0
+	}
+	return b.limit - b.used
+}
+
+// Reset 清空已用额度，并以 period 作为新的统计周期（<=0 则沿用原周期）
+func (b *MemoryBudget) Reset(period time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if period > 0 {
+		b.period = period
+	}
+	b.used = 0
+	b.periodStart = time.Now()
+}
+
+func (b *MemoryBudget) rollLocked() {
+	if b.period > 0 && time.Since(b.periodStart) >= b.period {
+		b.used = 0
+		b.periodStart = time.Now()
+	}
+}
+
+// estimateGPUSeconds 粗略估算一次生成的 GPU 秒数：Flux 在 1MP 下约 0.5 秒/步
+func estimateGPUSeconds(p *Params) float64 {
+	megapixels := float64(p.Width*p.Height) / 1e6
+	return float64(p.Steps) * megapixels * 0.5
+}
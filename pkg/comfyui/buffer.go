@@ -0,0 +1,81 @@
+package comfyui
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RequestBuffer 攒批提交：请求先进入缓冲区，数量达到 maxSize 或最早的请求等待超过 maxDelay 时，
+// 把缓冲区内的请求一起并发提交到 /prompt。ComfyUI 没有批量提交接口，攒批只是减少零散提交的调度开销
+type RequestBuffer struct {
+	client   *Client
+	maxSize  int
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	pending []*bufferedRequest
+	timer   *time.Timer
+}
+
+type bufferedRequest struct {
+	ctx      context.Context
+	workflow map[string]interface{}
+	done     chan submitResult
+}
+
+type submitResult struct {
+	promptID string
+	err      error
+}
+
+func NewRequestBuffer(c *Client, maxSize int, maxDelay time.Duration) *RequestBuffer {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+	return &RequestBuffer{client: c, maxSize: maxSize, maxDelay: maxDelay}
+}
+
+// Submit 把工作流放入缓冲区，等到所在批次提交后返回 prompt_id
+func (b *RequestBuffer) Submit(ctx context.Context, workflow map[string]interface{}) (string, error) {
+	req := &bufferedRequest{ctx: ctx, workflow: workflow, done: make(chan submitResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, req)
+	switch {
+	case len(b.pending) >= b.maxSize:
+		b.flushLocked()
+	case len(b.pending) == 1:
+		b.timer = time.AfterFunc(b.maxDelay, b.Flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case res := <-req.done:
+		return res.promptID, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Flush 立即提交缓冲区中的所有请求
+func (b *RequestBuffer) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+}
+
+func (b *RequestBuffer) flushLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	for _, req := range batch {
+		go func(req *bufferedRequest) {
+			promptID, err := b.client.submitWorkflow(req.ctx, req.workflow)
+			req.done <- submitResult{promptID: promptID, err: err}
+		}(req)
+	}
+}
@@ -0,0 +1,174 @@
+package comfyui
+
+import (
+	"strconv"
+	"sync"
+)
+
+// NodeRef 引用工作流中某个节点的第 Output 个输出，作为其它节点的输入
+type NodeRef struct {
+	NodeID string `json:"node_id" yaml:"node_id"`
+	Output int    `json:"output" yaml:"output"`
+}
+
+// wire 转为 ComfyUI API 格式的连线 ["节点ID", 输出序号]
+func (r NodeRef) wire() []interface{} {
+	return []interface{}{r.NodeID, r.Output}
+}
+
+// WorkflowBuilder 以代码方式拼装 ComfyUI API 格式的工作流，节点 ID 从 1 递增
+type WorkflowBuilder struct {
+	nodes  map[string]map[string]interface{}
+	nextID int
+	custom map[string]NodeSchema
+	// metadata 仅随 MarshalProto 保存
+	metadata map[string]string
+}
+
+func NewWorkflowBuilder() *WorkflowBuilder {
+	return &WorkflowBuilder{nodes: make(map[string]map[string]interface{})}
+}
+
+// AddNode 添加节点并返回节点 ID；classType 可以是 RegisterNodeAlias 注册的别名，inputs 中的 NodeRef 会转为连线
+func (b *WorkflowBuilder) AddNode(classType string, inputs map[string]interface{}) string {
+	b.nextID++
+	id := strconv.Itoa(b.nextID)
+	converted := make(map[string]interface{}, len(inputs))
+	for k, v := range inputs {
+		if ref, ok := v.(NodeRef); ok {
+			v = ref.wire()
+		}
+		converted[k] = v
+	}
+	b.nodes[id] = map[string]interface{}{
+		"inputs":     converted,
+		"class_type": resolveNodeAlias(classType),
+	}
+	return id
+}
+
+// Out 返回节点 nodeID 第 index 个输出的引用
+func (b *WorkflowBuilder) Out(nodeID string, index int) NodeRef {
+	return NodeRef{NodeID: nodeID, Output: index}
+}
+
+// Bypass 把节点标记为跳过（_meta.bypass = true），不存在的 ID 忽略；
+// /prompt 不识别该标记，Build 时移除这些节点并把下游连线改接到被跳过节点的输入，见 bypassedInput
+func (b *WorkflowBuilder) Bypass(nodeIDs ...string) {
+	for _, id := range nodeIDs {
+		node, ok := b.nodes[id]
+		if !ok {
+			continue
+		}
+		meta, _ := node["_meta"].(map[string]interface{})
+		if meta == nil {
+			meta = map[string]interface{}{}
+			node["_meta"] = meta
+		}
+		meta["bypass"] = true
+	}
+}
+
+// Build 返回可直接提交到 /prompt 的工作流
+func (b *WorkflowBuilder) Build() map[string]interface{} {
+	wf := make(map[string]interface{}, len(b.nodes))
+	bypassed := false
+	for id, node := range b.nodes {
+		if isBypassed(node) {
+			bypassed = true
+			continue
+		}
+		wf[id] = node
+	}
+	if !bypassed {
+		return wf
+	}
+	for id, v := range wf {
+		node := v.(map[string]interface{})
+		inputs, _ := node["inputs"].(map[string]interface{})
+		var rewired map[string]interface{}
+		for name, value := range inputs {
+			replacement, ok := b.bypassedInput(value, name)
+			if !ok {
+				continue
+			}
+			if rewired == nil {
+				rewired = make(map[string]interface{}, len(inputs))
+				for k, v := range inputs {
+					rewired[k] = v
+				}
+			}
+			rewired[name] = replacement
+		}
+		if rewired != nil {
+			cp := make(map[string]interface{}, len(node))
+			for k, v := range node {
+				cp[k] = v
+			}
+			cp["inputs"] = rewired
+			wf[id] = cp
+		}
+	}
+	return wf
+}
+
+// bypassedInput value 连到被跳过的节点时，返回替代它的值：优先取该节点同名输入（连线或常量，
+// 如跳过 BaiduTranslateNode 后 text 直接使用原文），否则取按名称排序的第一个连线输入；沿连续跳过的节点向上查找，
+// 都找不到时保留原连线，提交时由 ComfyUI 报告节点缺失
+func (b *WorkflowBuilder) bypassedInput(value interface{}, inputName string) (interface{}, bool) {
+	replaced := false
+	for i := 0; i <= len(b.nodes); i++ {
+		srcID, _, ok := parseWire(value)
+		if !ok {
+			return value, replaced
+		}
+		src, exists := b.nodes[srcID]
+		if !exists || !isBypassed(src) {
+			return value, replaced
+		}
+		inputs, _ := src["inputs"].(map[string]interface{})
+		next, same := inputs[inputName]
+		if !same {
+			next = nil
+			for _, name := range sortedKeys(inputs) {
+				if _, _, isWire := parseWire(inputs[name]); isWire {
+					next = inputs[name]
+					break
+				}
+			}
+			if next == nil {
+				return value, replaced
+			}
+		}
+		value, replaced = next, true
+	}
+	return value, replaced
+}
+
+func isBypassed(node map[string]interface{}) bool {
+	meta, _ := node["_meta"].(map[string]interface{})
+	bypass, _ := meta["bypass"].(bool)
+	return bypass
+}
+
+var (
+	nodeAliasMu sync.RWMutex
+	nodeAliases = map[string]string{}
+)
+
+// RegisterNodeAlias 注册节点别名，如 RegisterNodeAlias("prompt_encoder", "CLIPTextEncode")
+func RegisterNodeAlias(alias, classType string) {
+	nodeAliasMu.Lock()
+	defer nodeAliasMu.Unlock()
+	nodeAliases[alias] = classType
+}
+
+// resolveNodeAlias 返回别名对应的 class_type，未注册时原样返回
+func resolveNodeAlias(name string) string {
+	nodeAliasMu.RLock()
+	defer nodeAliasMu.RUnlock()
+	if classType, ok := nodeAliases[name]; ok {
+		return classType
+	}
+	return name
+}
@@ -0,0 +1,329 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// WorkflowGraph 各字段编号，与 api/workflow_graph.proto 保持一致
+const (
+	graphNodes    protowire.Number = 1
+	graphEdges    protowire.Number = 2
+	graphMetadata protowire.Number = 3
+	graphNextID   protowire.Number = 4
+
+	nodeID        protowire.Number = 1
+	nodeClassType protowire.Number = 2
+	nodeInputs    protowire.Number = 3
+
+	edgeSourceNodeID protowire.Number = 1
+	edgeSourceOutput protowire.Number = 2
+	edgeTargetNodeID protowire.Number = 3
+	edgeTargetInput  protowire.Number = 4
+
+	valueString protowire.Number = 1
+	valueNumber protowire.Number = 2
+	valueInt    protowire.Number = 3
+	valueBool   protowire.Number = 4
+	valueJSON   protowire.Number = 5
+
+	mapKey   protowire.Number = 1
+	mapValue protowire.Number = 2
+)
+
+// SetMetadata 设置随 MarshalProto 一起保存的元数据，不影响 Build 的结果
+func (b *WorkflowBuilder) SetMetadata(key, value string) {
+	if b.metadata == nil {
+		b.metadata = make(map[string]string)
+	}
+	b.metadata[key] = value
+}
+
+// MarshalProto 按 api/workflow_graph.proto 序列化当前状态；批量存库时比 JSON 更快、更小
+func (b *WorkflowBuilder) MarshalProto() ([]byte, error) {
+	var buf []byte
+	var edges [][]byte
+	for _, id := range sortedNodeIDs(b.nodes) {
+		node := b.nodes[id]
+		classType, _ := node["class_type"].(string)
+		inputs, _ := node["inputs"].(map[string]interface{})
+
+		var nb []byte
+		nb = protowire.AppendTag(nb, nodeID, protowire.BytesType)
+		nb = protowire.AppendString(nb, id)
+		nb = protowire.AppendTag(nb, nodeClassType, protowire.BytesType)
+		nb = protowire.AppendString(nb, classType)
+		for _, name := range sortedKeys(inputs) {
+			if src, out, ok := parseWire(inputs[name]); ok {
+				var eb []byte
+				eb = protowire.AppendTag(eb, edgeSourceNodeID, protowire.BytesType)
+				eb = protowire.AppendString(eb, src)
+				eb = protowire.AppendTag(eb, edgeSourceOutput, protowire.VarintType)
+				eb = protowire.AppendVarint(eb, uint64(int64(out)))
+				eb = protowire.AppendTag(eb, edgeTargetNodeID, protowire.BytesType)
+				eb = protowire.AppendString(eb, id)
+				eb = protowire.AppendTag(eb, edgeTargetInput, protowire.BytesType)
+				eb = protowire.AppendString(eb, name)
+				edges = append(edges, eb)
+				continue
+			}
+			vb, err := marshalProtoValue(inputs[name])
+			if err != nil {
+				return nil, fmt.Errorf("comfyui marshal node %s input %q: %w", id, name, err)
+			}
+			var entry []byte
+			entry = protowire.AppendTag(entry, mapKey, protowire.BytesType)
+			entry = protowire.AppendString(entry, name)
+			entry = protowire.AppendTag(entry, mapValue, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, vb)
+			nb = protowire.AppendTag(nb, nodeInputs, protowire.BytesType)
+			nb = protowire.AppendBytes(nb, entry)
+		}
+		buf = protowire.AppendTag(buf, graphNodes, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, nb)
+	}
+	for _, eb := range edges {
+		buf = protowire.AppendTag(buf, graphEdges, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, eb)
+	}
+	for _, k := range sortedStringKeys(b.metadata) {
+		var entry []byte
+		entry = protowire.AppendTag(entry, mapKey, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, mapValue, protowire.BytesType)
+		entry = protowire.AppendString(entry, b.metadata[k])
+		buf = protowire.AppendTag(buf, graphMetadata, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, entry)
+	}
+	buf = protowire.AppendTag(buf, graphNextID, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(int64(b.nextID)))
+	return buf, nil
+}
+
+// UnmarshalProto 用 MarshalProto 的输出替换当前状态
+func (b *WorkflowBuilder) UnmarshalProto(data []byte) error {
+	nodes := make(map[string]map[string]interface{})
+	metadata := map[string]string{}
+	nextID := 0
+	type edge struct {
+		src, target, input string
+		out                int
+	}
+	var edges []edge
+
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case graphNodes:
+			var id, classType string
+			inputs := map[string]interface{}{}
+			err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case nodeID:
+					id = string(v)
+				case nodeClassType:
+					classType = string(v)
+				case nodeInputs:
+					key, raw, err := consumeMapEntry(v)
+					if err != nil {
+						return err
+					}
+					val, err := unmarshalProtoValue(raw)
+					if err != nil {
+						return err
+					}
+					inputs[key] = val
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			nodes[id] = map[string]interface{}{"inputs": inputs, "class_type": classType}
+		case graphEdges:
+			var e edge
+			err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case edgeSourceNodeID:
+					e.src = string(v)
+				case edgeSourceOutput:
+					e.out = int(int64(n))
+				case edgeTargetNodeID:
+					e.target = string(v)
+				case edgeTargetInput:
+					e.input = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			edges = append(edges, e)
+		case graphMetadata:
+			key, raw, err := consumeMapEntry(v)
+			if err != nil {
+				return err
+			}
+			metadata[key] = string(raw)
+		case graphNextID:
+			nextID = int(int64(n))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("comfyui unmarshal workflow graph: %w", err)
+	}
+	for _, e := range edges {
+		node, ok := nodes[e.target]
+		if !ok {
+			return fmt.Errorf("comfyui unmarshal workflow graph: edge targets unknown node %s", e.target)
+		}
+		node["inputs"].(map[string]interface{})[e.input] = []interface{}{e.src, e.out}
+	}
+	b.nodes, b.nextID = nodes, nextID
+	b.metadata = nil
+	if len(metadata) > 0 {
+		b.metadata = metadata
+	}
+	return nil
+}
+
+func marshalProtoValue(v interface{}) ([]byte, error) {
+	var b []byte
+	switch t := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, valueString, protowire.BytesType)
+		b = protowire.AppendString(b, t)
+	case bool:
+		b = protowire.AppendTag(b, valueBool, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(t))
+	case int:
+		b = protowire.AppendTag(b, valueInt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(t)))
+	case int64:
+		b = protowire.AppendTag(b, valueInt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	case float64:
+		b = protowire.AppendTag(b, valueNumber, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(t))
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, valueJSON, protowire.BytesType)
+		b = protowire.AppendBytes(b, data)
+	}
+	return b, nil
+}
+
+func unmarshalProtoValue(data []byte) (interface{}, error) {
+	var out interface{}
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case valueString:
+			out = string(v)
+		case valueNumber:
+			out = math.Float64frombits(n)
+		case valueInt:
+			out = int(int64(n))
+		case valueBool:
+			out = protowire.DecodeBool(n)
+		case valueJSON:
+			return json.Unmarshal(v, &out)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// consumeFields 依次回调每个字段：变长 / 定长整数在 n 中，length-delimited 字段在 v 中
+func consumeFields(data []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(data) > 0 {
+		num, typ, tagLen := protowire.ConsumeTag(data)
+		if tagLen < 0 {
+			return protowire.ParseError(tagLen)
+		}
+		data = data[tagLen:]
+		var (
+			v   []byte
+			n   uint64
+			cnt int
+		)
+		switch typ {
+		case protowire.VarintType:
+			n, cnt = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			n, cnt = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			v, cnt = protowire.ConsumeBytes(data)
+		default:
+			cnt = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if cnt < 0 {
+			return protowire.ParseError(cnt)
+		}
+		data = data[cnt:]
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func consumeMapEntry(data []byte) (string, []byte, error) {
+	var key string
+	var value []byte
+	err := consumeFields(data, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case mapKey:
+			key = string(v)
+		case mapValue:
+			value = v
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+// sortedNodeIDs 按数值顺序排列节点 ID（非数字 ID 排在后面），保证序列化结果稳定
+func sortedNodeIDs(nodes map[string]map[string]interface{}) []string {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		if (errA == nil) != (errB == nil) {
+			return errA == nil
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package comfyui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkflowBuilderProtoRoundTrip(t *testing.T) {
+	b := NewWorkflowBuilder()
+	ckpt := b.AddNode("CheckpointLoaderSimple", map[string]interface{}{"ckpt_name": "v1-5.safetensors"})
+	latent := b.AddNode("EmptyLatentImage", map[string]interface{}{"width": 512, "height": 512, "batch_size": 1})
+	b.AddNode("KSampler", map[string]interface{}{
+		"model":        b.Out(ckpt, 0),
+		"latent_image": b.Out(latent, 0),
+		"seed":         42,
+		"cfg":          7.5,
+		"denoise":      1.0,
+		"add_noise":    true,
+		"tags":         []interface{}{"a", "b"},
+	})
+	b.SetMetadata("scene", "s1")
+
+	data, err := b.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := b.MarshalProto()
+	if err != nil || string(again) != string(data) {
+		t.Fatal("MarshalProto is not deterministic")
+	}
+
+	got := NewWorkflowBuilder()
+	if err := got.UnmarshalProto(data); err != nil {
+		t.Fatal(err)
+	}
+	want := b.Build()
+	if !reflect.DeepEqual(got.Build(), want) {
+		t.Fatalf("round trip mismatch:\n got %v\nwant %v", got.Build(), want)
+	}
+	if got.metadata["scene"] != "s1" {
+		t.Fatalf("metadata = %v", got.metadata)
+	}
+	if id := got.AddNode("SaveImage", nil); id != b.AddNode("SaveImage", nil) {
+		t.Fatalf("next id after round trip = %s", id)
+	}
+}
@@ -0,0 +1,55 @@
+package comfyui
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// cancelTimeout ctx 结束后取消服务器端任务的超时时间
+const cancelTimeout = 5 * time.Second
+
+// Cancel 取消 promptID：排队中的任务从队列删除（POST /queue {"delete": [id]}），正在执行的任务中断（POST /interrupt）；
+// 任务已结束或不存在时什么也不做
+func (c *Client) Cancel(ctx context.Context, promptID string) error {
+	position, running, err := c.QueuePosition(ctx, promptID)
+	if err != nil {
+		return err
+	}
+	if position >= 0 && !running {
+		err := c.callAPI("/queue", func(cl *api.Client) (*http.Response, error) {
+			return cl.PostQueue(ctx, api.PostQueueJSONRequestBody{Delete: []string{promptID}})
+		})
+		if err != nil {
+			return err
+		}
+		// 删除请求到达前任务可能已开始执行，此时 delete 不生效，需要再中断
+		if _, running, err = c.QueuePosition(ctx, promptID); err != nil {
+			return err
+		}
+	}
+	if !running {
+		return nil
+	}
+	if err := c.requireFeature(ctx, FeatureInterrupt); err != nil {
+		return err
+	}
+	// 新版 ComfyUI 只中断 prompt_id 对应的任务，旧版忽略该字段、中断当前任务（此时当前任务即为 promptID）
+	return c.callAPI("/interrupt", func(cl *api.Client) (*http.Response, error) {
+		return cl.PostInterrupt(ctx, api.PostInterruptJSONRequestBody{PromptID: promptID})
+	})
+}
+
+// cancelAbandoned CancelOnContextDone 为 true 且 ctx 已结束时取消服务器端的 promptID，避免被放弃的任务继续占用 GPU
+func (c *Client) cancelAbandoned(ctx context.Context, promptID string) {
+	if !c.CancelOnContextDone || ctx.Err() == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+	if err := c.Cancel(ctx, promptID); err != nil {
+		c.warnw("ComfyUI cancel abandoned prompt failed", "prompt_id", promptID, "error", err)
+	}
+}
@@ -0,0 +1,61 @@
+package comfyui
+
+import (
+	"unicode"
+)
+
+// ParamOption 构造 Params 时的可选项
+type ParamOption func(*Params)
+
+// NewParams 以 prompt 创建 Params 并依次应用 opts
+func NewParams(prompt string, opts ...ParamOption) *Params {
+	p := &Params{Prompt: prompt}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// dynamicCFGTargetTokens 默认以 CLIP 的 77 token 上限作为"长 prompt"基准
+const dynamicCFGTargetTokens = 77
+
+// WithDynamicCFG 按 prompt 长度自动设置 CFG（见 ComputeDynamicCFG）
+func WithDynamicCFG() ParamOption {
+	return func(p *Params) {
+		p.CFG = ComputeDynamicCFG(p.Prompt, dynamicCFGTargetTokens)
+	}
+}
+
+// ComputeDynamicCFG 估算 prompt 的 token 数，在 0..targetTokenCount 区间内把 CFG 从 1.0 线性提高到 3.5
+func ComputeDynamicCFG(prompt string, targetTokenCount int) float64 {
+	const minCFG, maxCFG = 1.0, 3.5
+	if targetTokenCount <= 0 {
+		return minCFG
+	}
+	ratio := float64(countPromptTokens(prompt)) / float64(targetTokenCount)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return minCFG + (maxCFG-minCFG)*ratio
+}
+
+// countPromptTokens 粗略统计 token：英文按单词计，中日韩字符每字计 1 个
+func countPromptTokens(prompt string) int {
+	n := 0
+	inWord := false
+	for _, r := range prompt {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+				inWord = true
+			}
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
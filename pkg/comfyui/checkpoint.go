@@ -0,0 +1,20 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+)
+
+// resumeWorkflow 节点执行失败且已有节点成功时重新提交一次同一工作流。
+// ComfyUI 不支持把中间结果（模型、latent 等）带入新的 prompt，但会按节点输入缓存已执行节点的输出，
+// 输入未变的节点（出错位置之前的模型加载、文本编码等）在重新提交时直接命中缓存，只需从失败节点继续执行；
+// 适合 CUDA OOM 等偶发错误，配置错误重试后仍会失败并返回新的错误
+func (c *Client) resumeWorkflow(ctx context.Context, workflow map[string]interface{}, err error, cb func(event ProgressEvent)) ([]string, error) {
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || len(execErr.Executed) == 0 || ctx.Err() != nil {
+		return nil, err
+	}
+	c.warnw("ComfyUI node failed, resubmitting from cached nodes",
+		"prompt_id", execErr.PromptID, "node_id", execErr.NodeID, "executed", execErr.Executed, "error", err)
+	return c.executeWorkflow(ctx, workflow, allOutputNodes, cb)
+}
@@ -1,12 +1,22 @@
 package comfyui
 
 import (
-	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+	"github.com/drama-generator/backend/pkg/logger"
 )
 
 // Client 调用 ComfyUI API 提交工作流并轮询结果（与 file1.html 中 Flux 文生图工作流一致）
@@ -14,185 +24,735 @@ type Client struct {
 	BaseURL  string
 	ClientID string
 	HTTP     *http.Client
+	// ValidateResult 为 true 时，拿到结果后再查询一次 history，校验返回的 URL 与记录一致（多一次 HTTP 请求）
+	ValidateResult bool
+	// ReturnBase64 为 true 时 Generate 会下载图片并在结果中附带 base64，省去浏览器再请求一次
+	ReturnBase64 bool
+	// EnsureModels 为 true 时提交前用 EnsureModelsLoaded 确认工作流引用的模型都在服务器上（每次多几次 HTTP 请求）
+	EnsureModels bool
+	// AvailableVRAMGB 服务器可用显存；>0 时提交前用 ScoreWorkflow 预估，超出则返回 ErrInsufficientVRAM
+	AvailableVRAMGB float64
+	// EmbedParamsInImage 为 true 时下载图片并把 Params（JSON）写入 PNG 的 iTXt "generation_params" 块，
+	// 结果在 GenerateResult.ImageData 中；可用 ReadPNGText 读回
+	EmbedParamsInImage bool
+	// BlankOutputDetection 为 true 时检查输出是否几乎全黑/全白（显存异常时偶发），是则重新生成；
+	// 配置了 OutputDir 时同时删除空白输出文件
+	BlankOutputDetection bool
+	// OutputDir ComfyUI output 目录在本机的路径（同机部署或共享挂载），DeleteOutputFiles 与空白图清理直接删除其中的文件；
+	// ComfyUI 的 HTTP API 没有删除接口，为空时无法删除输出文件
+	OutputDir      string
+	BlankThreshold float64 // 接近纯黑/纯白像素占比超过该值视为空白图，默认 0.99
+	MaxRetries     int     // 生成失败后的重试次数，默认 0 不重试
+	// ValidateAspectRatio 为 true 时下载图片并校验宽高比与 Params.Width / Height 相差不超过 2%，否则返回 ErrAspectRatioMismatch
+	ValidateAspectRatio bool
+	// Concurrency GenerateBatch 的最大并发数，默认 1
+	Concurrency int
+	// BatchSubmitDelay GenerateBatch 相邻两次提交之间的间隔，避免短时间大量提交压垮服务器
+	BatchSubmitDelay time.Duration
+	// OnProgress 轮询期间回调进度；HTTP 轮询拿不到真实步数，按历史耗时中位数估算 step/total
+	OnProgress func(step, total int)
+	// OnProgressEvent 使用 WebSocket 时回调每个执行事件（排队位置、逐步进度、当前节点），Generate 等所有入口都生效；
+	// 连接失败退化为轮询时不回调，只有 OnProgress 的估算进度
+	OnProgressEvent func(event ProgressEvent)
+	// ModelAliases 非空时，Params.UNETModelName 以字母开头则视为别名并解析为实际模型路径
+	ModelAliases *ModelAliasRegistry
+	// Presets 可通过 GenerateFromPreset 按名称提交的工作流，一般由 LoadWorkflowPresets 加载
+	Presets map[string]map[string]interface{}
+	// DynamicPresets 为 true 时 GenerateFromPreset 每次都从 PresetsDir 读取预设（按文件修改时间缓存），
+	// 便于不重启服务热更新工作流，代价是每次请求多几次 stat；此时忽略 Presets
+	DynamicPresets bool
+	PresetsDir     string
+	// FingerprintStore 非空时 GenerateFromPreset 会检测预设工作流是否在两次运行之间被修改
+	FingerprintStore WorkflowFingerprintStore
+	// Annotations 图片标注存储，Annotate / GetAnnotations 使用
+	Annotations AnnotationStore
+	// AutoReconnect 为 true 时请求遇到连接被拒绝（如容器 OOM 后重启）会按指数退避重试，直到 ctx 结束
+	AutoReconnect     bool
+	MaxReconnectDelay time.Duration // 退避间隔上限，默认 30s
+	// OnReconnecting 每次重连前回调，attempt 从 1 开始
+	OnReconnecting func(attempt int, delay time.Duration)
+	// OnReconnected 重连成功后回调
+	OnReconnected func(attempts int)
+	// OnStateChange 连接状态（见 ConnectionState）变化时回调，可用于状态面板
+	OnStateChange func(old, new ConnectionState)
+	// SnapshotPolling 为 true 时每次轮询 history 的结果都写入 SnapshotDir/<prompt_id>/ 下的 JSON 文件，
+	// 生成结果异常时可按时间线排查
+	SnapshotPolling bool
+	SnapshotDir     string
+	// ResultTTL 缓存结果的有效期（应不超过 ComfyUI 清理输出文件的周期），过期后 GetCachedResult 返回 ErrCacheExpired；
+	// 0 表示永不过期
+	ResultTTL time.Duration
+	// MaxWorkflowSizeBytes 提交的工作流 JSON 上限，超出返回 ErrWorkflowTooLarge，默认 1MB
+	MaxWorkflowSizeBytes int
+	// MaxImageBytes DownloadImage 及下载参考图 / 起始图时允许的最大图片大小，默认 50MB
+	MaxImageBytes int64
+	// HealthCheckTTL HealthCheck 结果的有效期，超出后 /livez 返回 503，默认 30s
+	HealthCheckTTL time.Duration
+	// UseWebSocketIfAvailable 为 true 时首次生成前探测 /ws，可用则通过 WebSocket 接收进度与结果，否则轮询 /history；
+	// NewClient 默认开启，直接构造 Client 时需显式设置
+	UseWebSocketIfAvailable bool
+	// FilenameStrategy 输出文件命名方式，为空时为 comfy_ui_generated（或 Params.SeedInFilename 的 drama_<seed>）
+	FilenameStrategy FilenameStrategy
+	// OutputDatePrefix 为 true 时输出保存到 output/YYYY-MM-DD/ 子目录，便于多个项目共用一台 ComfyUI 时按日期归档
+	OutputDatePrefix bool
+	// CheckpointWorkflow 为 true 时，节点执行失败后重新提交一次工作流，已成功的节点（如模型加载）由 ComfyUI 的缓存直接复用，见 resumeWorkflow
+	CheckpointWorkflow bool
+	// Calibration 实测的每步采样耗时，供 WithDeadlineAdaptation 估算可用步数
+	Calibration *CalibrationResult
+	// ProjectQuota 项目 ID（Params.Metadata["project_id"]）-> 每天最多生成次数，超出时 Generate 返回 ErrProjectQuotaExceeded；
+	// 计数保存在进程内，多实例部署时各自统计
+	ProjectQuota map[string]int
+	// OnQueueDepthChanged StartHealthMonitor 发现队列深度（执行中 + 排队中）相对上次通知变化超过 10% 时回调，
+	// 首次采集也会回调一次；可用来上报 HPA 自定义指标，按队列深度伸缩 ComfyUI 实例
+	OnQueueDepthChanged func(depth int)
+	// RecordStepPreviews 为 true 时保存 WebSocket 推送的逐步预览帧，供 FetchStepPreviews 读取（需 ComfyUI 启动时指定 --preview-method）
+	RecordStepPreviews bool
+	// PollInterval 轮询 /history 的间隔，默认 1s；PollTimeout 等待结果的上限（WebSocket 模式同样适用），默认 300s
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+	// FallbackImagePath 非空时，等待结果超过 PollTimeout 后上传该本地图片并返回其 /view 地址而不是 ErrPollingTimeout，
+	// 适合直播等宁可显示占位图也不能报错的场景
+	FallbackImagePath string
+	// CancelOnContextDone 为 true 时，Generate 等待期间 ctx 被取消或超时会用 Cancel 从服务器删除 / 中断该任务，
+	// 否则 ComfyUI 会继续执行已放弃的任务
+	CancelOnContextDone bool
+	// MaxRateLimitRetries 提交与轮询遇到 429 时最多重试的次数，超出返回 ErrRateLimited，默认 3
+	MaxRateLimitRetries int
+	// ErrorLocale 为 "zh" 时 Generate / GenerateWorkflow 返回中文错误信息，errors.Is / errors.As 不受影响
+	ErrorLocale string
+	// Logger 可选，为空时不输出日志
+	Logger *logger.Logger
+
+	budget        BudgetTracker
+	quota         QuotaManager
+	projects      projectCounter
+	deadline      *deadlineAdaptation
+	thumbnail     *thumbnailConfig
+	outputRoutes  []OutputRoute
+	resultStorage StorageBackend
+	previews      stepPreviewLog
+	// NewClientFromConfig 加载的默认参数与参数预设
+	defaultParams *Params
+	paramsPresets map[string]*Params
+	templates     map[string]*WorkflowTemplate
+	version       serverVersionCache
+	rateLimiter   RateLimiter
+	durations     durationHistory
+	presetCache   presetCache
+	stats         executionStats
+	health        healthState
+	results       resultCache
+
+	// HTTP 为空时懒加载的默认 http.Client，sync.Once 保证并发调用时只创建一次
+	defaultHTTPOnce sync.Once
+	defaultHTTP     *http.Client
+
+	// WebSocket 探测结果，只探测一次
+	wsProbeOnce sync.Once
+	wsAvailable bool
+
+	// Abort 使用的共享 context，首次使用时创建
+	abortOnce   sync.Once
+	abortCtx    context.Context
+	abortCancel context.CancelFunc
+	aborted     atomic.Bool
+
+	// connState 当前 ConnectionState
+	connState atomic.Int32
+}
+
+// GenerateResult Generate 的返回结果
+type GenerateResult struct {
+	ImageURL string // 完整图片地址（BaseURL + /view?filename=...）
+	// ImageURLs 全部输出节点的全部图片（按节点 ID 顺序，ImageURLs[0] 即 ImageURL），BatchSize > 1 时每个候选各一张
+	ImageURLs   []string
+	ImageBase64 string // 仅 Client.ReturnBase64 为 true 时填充
+	ImageData   []byte // 下载到的图片内容（ReturnBase64、EmbedParamsInImage 或 Params.LocationStamp 启用时填充）
+	// NodeErrors 执行失败的节点；非空时 Generate 同时返回 *NodeExecutionError
+	NodeErrors map[string]string
+	// DryRun 仅 Params.DryRun 为 true 时填充，此时 ImageURL 为空
+	DryRun *DryRunResult
+	// Filename 仅 Client.FilenameStrategy 为 FilenameContentHash 时填充，为图片内容的 "<sha256>.png"
+	Filename string
+	// Asset 仅配置了 WithResultStorage 时填充，为持久化后的地址与内容信息
+	Asset *StoredAsset
+	// RoutedURLs 仅配置了 WithOutputRoutes 时填充：ComfyUI /view 地址 -> 上传后的存储地址
+	RoutedURLs map[string]string
+}
+
+// Generate 提交工作流并等待完成，返回生成图片的 URL（及可选的 base64 内容）；
+// ctx 取消或超时时立即停止轮询并返回（可用 errors.Is 判断 context.Canceled / context.DeadlineExceeded）
+func (c *Client) Generate(ctx context.Context, p *Params) (*GenerateResult, error) {
+	c.applyDefaultParams(p)
+	// 在填充默认值之前计算缓存键，与调用方对同一 Params 计算的 ParamsHash 一致
+	key := ParamsHash(p)
+	start := time.Now()
+	result, err := c.generateResult(ctx, p)
+	c.stats.record(time.Since(start), err)
+	if err == nil && result.ImageURL != "" {
+		c.CacheResult(key, result.ImageURL)
+	}
+	return result, c.localizeError(err)
+}
+
+// GenerateWorkflow 提交调用方构建的任意 API 格式工作流（SDXL、LoRA、视频等），等待完成并返回
+// outputNodeID 节点输出的全部图片 URL；outputNodeID 为空时取第一个有图片输出的节点
+func (c *Client) GenerateWorkflow(ctx context.Context, workflow map[string]interface{}, outputNodeID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.withAbort(ctx)
+	defer cancel()
+	urls, err := c.executeWorkflow(ctx, workflow, outputNodeID, nil)
+	return urls, c.localizeError(c.abortErr(err))
+}
+
+// generateResult Generate 的实现：生成、按需下载、空白图重试、写入元数据
+func (c *Client) generateResult(ctx context.Context, p *Params) (*GenerateResult, error) {
+	if p.DryRun {
+		dr, err := c.dryRun(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		return &GenerateResult{DryRun: dr}, nil
+	}
+	for attempt := 0; ; attempt++ {
+		var routed map[string]string
+		attemptCtx := ctx
+		if len(c.outputRoutes) > 0 {
+			routed = make(map[string]string)
+			attemptCtx = withRoutedURLs(ctx, routed)
+		}
+		urls, err := c.generateAll(attemptCtx, p, nil, nil)
+		if err != nil {
+			var nodeErr *NodeExecutionError
+			if errors.As(err, &nodeErr) {
+				return &GenerateResult{NodeErrors: nodeErr.NodeErrors}, err
+			}
+			var execErr *ExecutionError
+			if errors.As(err, &execErr) {
+				return &GenerateResult{NodeErrors: map[string]string{execErr.NodeID: execErr.ExceptionMessage}}, err
+			}
+			return nil, err
+		}
+		imageURL := urls[0]
+		result := &GenerateResult{ImageURL: imageURL, ImageURLs: urls, RoutedURLs: routed}
+		if !c.ReturnBase64 && !c.EmbedParamsInImage && !c.BlankOutputDetection && !c.ValidateAspectRatio && c.FilenameStrategy != FilenameContentHash && p.LocationStamp == nil && c.resultStorage == nil {
+			return result, nil
+		}
+		data, err := c.fetch(ctx, imageURL)
+		if err != nil {
+			return nil, err
+		}
+		if c.BlankOutputDetection && isBlankImage(data, c.blankThreshold()) {
+			if err := c.deleteImageURL(ctx, imageURL); err != nil && !errors.Is(err, ErrDeleteUnsupported) {
+				c.warnw("ComfyUI delete blank output failed", "url", imageURL, "error", err)
+			}
+			if attempt < c.MaxRetries {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %s", ErrBlankOutput, imageURL)
+		}
+		if c.ValidateAspectRatio {
+			if err := checkAspectRatio(data, p.Width, p.Height); err != nil {
+				return nil, err
+			}
+		}
+		if c.EmbedParamsInImage {
+			paramsJSON, _ := json.Marshal(p)
+			if data, err = EmbedPNGText(data, ParamsPNGKeyword, string(paramsJSON)); err != nil {
+				return nil, err
+			}
+		}
+		if p.LocationStamp != nil {
+			if data, err = EmbedPNGGPS(data, *p.LocationStamp); err != nil {
+				return nil, err
+			}
+		}
+		result.ImageData = data
+		if c.FilenameStrategy == FilenameContentHash {
+			result.Filename = contentHashFilename(data)
+		}
+		if c.ReturnBase64 {
+			result.ImageBase64 = base64.StdEncoding.EncodeToString(data)
+		}
+		if c.resultStorage != nil {
+			filename := result.Filename
+			if filename == "" {
+				filename = path.Base(cleanFilename(imageURL))
+			}
+			if result.Asset, err = storeAsset(c.resultStorage, data, filename); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// generate 填充默认参数、构建工作流并等待结果，返回第一张图片；patch 非空时可在提交前修改工作流，cb 非空时接收执行事件
+func (c *Client) generate(ctx context.Context, p *Params, patch func(workflow map[string]interface{}) error, cb func(event ProgressEvent)) (string, error) {
+	urls, err := c.generateAll(ctx, p, patch, cb)
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+// generateAll 同 generate，返回全部输出节点的全部图片（按节点 ID 顺序）
+func (c *Client) generateAll(ctx context.Context, p *Params, patch func(workflow map[string]interface{}) error, cb func(event ProgressEvent)) ([]string, error) {
+	c.applyDefaultParams(p)
+	ctx, cancel := c.withAbort(ctx)
+	defer cancel()
+	ctx = withRequestID(ctx, p.RequestID)
+	ctx = withSafetyCheck(ctx, p.SafetyCheck)
+	workflow, err := c.prepareWorkflow(ctx, p, patch)
+	if err != nil {
+		return nil, c.abortErr(err)
+	}
+	urls, err := c.executeWorkflow(ctx, workflow, allOutputNodes, cb)
+	if err != nil && c.CheckpointWorkflow {
+		urls, err = c.resumeWorkflow(ctx, workflow, err, cb)
+	}
+	if errors.Is(err, ErrPollingTimeout) && c.FallbackImagePath != "" {
+		c.warnw("ComfyUI generation timed out, returning fallback image", "path", c.FallbackImagePath)
+		imageURL, err := c.fallbackImage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []string{imageURL}, nil
+	}
+	return urls, c.abortErr(err)
+}
+
+// prepareWorkflow 提交前的准备：填充默认参数、构建（或按模板渲染）工作流，再经 admitWorkflow 做模型 / 显存 / 预算 / 配额检查；
+// 所有提交路径（Generate、Submit、模板与 WorkflowOverride）都经过这里
+func (c *Client) prepareWorkflow(ctx context.Context, p *Params, patch func(workflow map[string]interface{}) error) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.ModelFamily != "" {
+		*p = *NormalizeForModel(p, p.ModelFamily)
+	}
+	applyDefaults(p)
+	if _, err := c.baseURL(); err != nil {
+		return nil, err
+	}
+	workflow, err := c.buildOrRender(ctx, p, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.admitWorkflow(ctx, p, workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// usesTemplate 是否以模板 / WorkflowOverride / 内置默认工作流代替内置构建
+func (p *Params) usesTemplate() bool {
+	return p.Template != "" || len(p.WorkflowOverride) > 0 || p.UseDefaultWorkflow
+}
+
+// buildOrRender 按 Params 构建工作流；使用模板时不支持需要改写内置工作流的 Img2Img 与 patch，返回 ErrTemplateUnsupported
+func (c *Client) buildOrRender(ctx context.Context, p *Params, patch func(workflow map[string]interface{}) error) (map[string]interface{}, error) {
+	if p.usesTemplate() {
+		if p.Img2Img != nil {
+			return nil, fmt.Errorf("%w: Img2Img", ErrTemplateUnsupported)
+		}
+		if patch != nil {
+			return nil, fmt.Errorf("%w: workflow patch", ErrTemplateUnsupported)
+		}
+		return c.renderWorkflow(p)
+	}
+	if c.ModelAliases != nil && startsWithLetter(p.UNETModelName) {
+		p.UNETModelName = c.ModelAliases.ResolveModelName(p.UNETModelName)
+	}
+	if len(p.UNETShards) > 0 {
+		if err := c.requireNode(ctx, ShardedUNETLoaderClass); err != nil {
+			return nil, err
+		}
+	}
+	workflow := c.buildWorkflowFromContext(ctx, p)
+	if p.Img2Img != nil {
+		if err := c.applyImg2Img(ctx, workflow, p.Img2Img); err != nil {
+			return nil, err
+		}
+	}
+	if patch != nil {
+		if err := patch(workflow); err != nil {
+			return nil, err
+		}
+	}
+	return workflow, nil
+}
+
+type chargeOnceKey struct{}
+
+// withChargeOnce 同一 ctx 下多次提交（如 Pool 换实例重试）只扣一次预算与配额
+func withChargeOnce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, chargeOnceKey{}, new(bool))
 }
 
-// Params 文生图参数
-type Params struct {
-	Prompt string
-	Width  int // 默认 1920（宽）
-	Height int // 默认 1080（高）
-	Steps  int // 默认 25
-	CFG    float64
-	Seed   int64
-	// 可选：百度翻译 API（工作流含 BaiduTranslateNode 时使用，为空则不走翻译）
-	BaiduTranslateAppID  string
-	BaiduTranslateAppKey string
+// admitWorkflow 与工作流来源无关的提交检查：模型是否存在、显存估算，以及预算 / 项目配额 / 租户配额扣减（DryRun 不扣减）
+func (c *Client) admitWorkflow(ctx context.Context, p *Params, workflow map[string]interface{}) error {
+	if c.EnsureModels {
+		if err := c.EnsureModelsLoaded(ctx, workflow); err != nil {
+			return err
+		}
+	}
+	if c.AvailableVRAMGB > 0 {
+		if score := ScoreWorkflow(workflow); score.EstimatedVRAMGB > c.AvailableVRAMGB {
+			return fmt.Errorf("%w: need %.1fGB, have %.1fGB", ErrInsufficientVRAM, score.EstimatedVRAMGB, c.AvailableVRAMGB)
+		}
+	}
+	charged, _ := ctx.Value(chargeOnceKey{}).(*bool)
+	if p.DryRun || (charged != nil && *charged) {
+		return nil
+	}
+	if c.budget != nil {
+		if err := c.budget.Consume(ctx, estimateGPUSeconds(p)); err != nil {
+			return err
+		}
+	}
+	if err := c.checkProjectQuota(p); err != nil {
+		return err
+	}
+	if c.quota != nil && p.TenantID != "" {
+		if err := c.quota.Deduct(ctx, p.TenantID, quotaCost(p)); err != nil {
+			return err
+		}
+	}
+	if charged != nil {
+		*charged = true
+	}
+	return nil
 }
 
-// Generate 提交工作流并等待完成，返回生成图片的完整 URL（BaseURL + /view?filename=...）
-func (c *Client) Generate(p *Params) (imageURL string, err error) {
+func applyDefaults(p *Params) {
 	if p.Width <= 0 {
 		p.Width = 1920
 	}
 	if p.Height <= 0 {
 		p.Height = 1080
 	}
-	if p.Steps <= 0 {
-		p.Steps = 25
+	p.SamplerConfig.applyPreset(p.SamplerPreset)
+	if p.isRandomSeed() {
+		p.Seed = time.Now().UnixNano() % 100000000000000
 	}
-	if p.CFG <= 0 {
-		p.CFG = 1
+}
+
+var (
+	// ErrWorkflowTooLarge 工作流 JSON 超过 Client.MaxWorkflowSizeBytes
+	ErrWorkflowTooLarge = errors.New("comfyui workflow too large")
+	// ErrSubmitFailed 向 /prompt 提交工作流失败
+	ErrSubmitFailed = errors.New("comfyui submit failed")
+	// ErrPollingTimeout 等待结果超时
+	ErrPollingTimeout = errors.New("comfyui timeout waiting for result")
+	// ErrNoOutput 任务已完成但没有输出图片
+	ErrNoOutput = errors.New("comfyui prompt finished without output images")
+)
+
+// SubmitError /prompt 返回非 200 状态码，errors.Is(err, ErrSubmitFailed) 为 true；
+// 400 表示工作流本身无效（如 invalid_prompt），换实例重试也不会成功
+type SubmitError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *SubmitError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrSubmitFailed, e.Status, e.Body)
+}
+
+func (e *SubmitError) Unwrap() error { return ErrSubmitFailed }
+
+// PollingTimeoutError 等待 PromptID 的结果超时，errors.Is(err, ErrPollingTimeout) 为 true；
+// 此时任务可能仍在服务器上排队或执行，需要时用 Cancel 释放
+type PollingTimeoutError struct {
+	PromptID string
+}
+
+func (e *PollingTimeoutError) Error() string {
+	return fmt.Sprintf("%s: prompt %s", ErrPollingTimeout, e.PromptID)
+}
+
+func (e *PollingTimeoutError) Unwrap() error { return ErrPollingTimeout }
+
+// defaultMaxWorkflowSizeBytes 工作流 JSON 的默认上限
+const defaultMaxWorkflowSizeBytes = 1 << 20
+
+// marshalWorkflow 序列化工作流并检查大小，内嵌 base64 预览图的工作流可能超出服务器限制
+func (c *Client) marshalWorkflow(workflow map[string]interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui marshal workflow: %w", err)
 	}
-	if p.Seed == 0 {
-		p.Seed = time.Now().UnixNano() % 100000000000000
+	limit := c.MaxWorkflowSizeBytes
+	if limit <= 0 {
+		limit = defaultMaxWorkflowSizeBytes
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrWorkflowTooLarge, len(data), limit)
 	}
+	return data, nil
+}
+
+// submitWorkflow 以 clientID 提交工作流到 /prompt，返回 prompt_id
+func (c *Client) submitWorkflow(ctx context.Context, workflow map[string]interface{}) (string, error) {
+	return c.submitPrompt(ctx, workflow, c.clientID(ctx))
+}
+
+type requestIDKey struct{}
 
-	workflow := c.buildWorkflow(p.Prompt, p.Width, p.Height, p.Steps, p.CFG, p.Seed, p.BaiduTranslateAppID, p.BaiduTranslateAppKey)
-	baseURL := c.BaseURL
-	if baseURL == "" {
-		return "", fmt.Errorf("comfyui base_url is required")
+// withRequestID 记录 Params.RequestID，提交时拼入 client_id
+func withRequestID(ctx context.Context, requestID string) context.Context {
+	if requestID == "" {
+		return ctx
 	}
-	if baseURL[len(baseURL)-1] == '/' {
-		baseURL = baseURL[:len(baseURL)-1]
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// clientID 提交使用的 client_id：Client.ClientID（默认 huobao_drama），ctx 带 RequestID 时追加 "-<RequestID>"
+func (c *Client) clientID(ctx context.Context) string {
+	id := c.ClientID
+	if id == "" {
+		id = "huobao_drama"
 	}
-	clientID := c.ClientID
-	if clientID == "" {
-		clientID = "huobao_drama"
+	if requestID, _ := ctx.Value(requestIDKey{}).(string); requestID != "" {
+		id += "-" + requestID
 	}
+	return id
+}
 
-	body, _ := json.Marshal(map[string]interface{}{
-		"prompt":    workflow,
-		"client_id": clientID,
-	})
-	req, err := http.NewRequest("POST", baseURL+"/prompt", bytes.NewReader(body))
+// submitPrompt 提交工作流到 /prompt，返回 prompt_id
+func (c *Client) submitPrompt(ctx context.Context, workflow map[string]interface{}, clientID string) (string, error) {
+	cl, err := c.apiClient(true)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	hc := c.HTTP
-	if hc == nil {
-		hc = &http.Client{Timeout: 30 * time.Second}
+	workflowJSON, err := c.marshalWorkflow(workflow)
+	if err != nil {
+		return "", err
+	}
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return "", err
+		}
 	}
-	resp, err := hc.Do(req)
+
+	resp, err := cl.PostPrompt(ctx, api.PromptRequest{Prompt: workflowJSON, ClientID: clientID})
 	if err != nil {
-		return "", fmt.Errorf("comfyui submit: %w", err)
+		return "", fmt.Errorf("%w: %w", ErrSubmitFailed, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("comfyui submit %s: %s", resp.Status, string(b))
+		return "", &SubmitError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(b)}
 	}
 
-	var submitResp struct {
-		PromptID string `json:"prompt_id"`
-	}
+	var submitResp api.PromptResponse
 	if err := json.NewDecoder(resp.Body).Decode(&submitResp); err != nil {
-		return "", fmt.Errorf("comfyui decode submit response: %w", err)
+		return "", fmt.Errorf("%w: decode response: %w", ErrSubmitFailed, err)
 	}
 	if submitResp.PromptID == "" {
-		return "", fmt.Errorf("comfyui no prompt_id in response")
+		return "", fmt.Errorf("%w: no prompt_id in response", ErrSubmitFailed)
+	}
+	return submitResp.PromptID, nil
+}
+
+// waitForImages 轮询 /history/{prompt_id}，直到任务完成，返回 outputNodeID 节点的全部输出图片；
+// outputNodeID 为空时取第一个有图片输出的节点
+func (c *Client) waitForImages(ctx context.Context, promptID, outputNodeID string) ([]string, error) {
+	start := time.Now()
+	entry, err := c.waitForEntry(ctx, promptID)
+	if err != nil {
+		return nil, err
 	}
+	return c.entryImages(ctx, promptID, entry, outputNodeID, start)
+}
 
-	// 轮询 /history/{prompt_id}
-	for i := 0; i < 300; i++ {
-		time.Sleep(1 * time.Second)
-		histReq, _ := http.NewRequest("GET", baseURL+"/history/"+submitResp.PromptID, nil)
-		histResp, err := hc.Do(histReq)
-		if err != nil {
+// allOutputNodes 作为 outputNodeID 时取全部输出节点的图片，见 HistoryEntry.AllImages
+const allOutputNodes = "*"
+
+// entryImages 从已完成的 history 记录中取出输出图片 URL 并收尾
+func (c *Client) entryImages(ctx context.Context, promptID string, entry *HistoryEntry, outputNodeID string, start time.Time) ([]string, error) {
+	imgs := entry.outputImages(outputNodeID)
+	if len(imgs) == 0 {
+		return nil, fmt.Errorf("%w: prompt %s", ErrNoOutput, promptID)
+	}
+	return c.finishImages(ctx, promptID, outputNodeID, imgs, start)
+}
+
+// waitForEntry 轮询 /history/{prompt_id}，直到任务出现在 history 中（ComfyUI 在整个 prompt 执行完毕后才写入）
+func (c *Client) waitForEntry(ctx context.Context, promptID string) (*HistoryEntry, error) {
+	start := time.Now()
+	median := c.durations.median()
+	interval := c.pollEvery()
+	attempts := max(1, int(c.pollTimeout()/interval))
+	for i := 0; i < attempts; i++ {
+		if c.aborted.Load() {
+			return nil, ErrAborted
+		}
+		if err := sleepCtx(ctx, interval); err != nil {
+			return nil, err
+		}
+		entry, err := c.GetHistory(ctx, promptID)
+		if c.SnapshotPolling {
+			c.writePollSnapshot(promptID, i, entry, err)
+		}
+		if errors.Is(err, ErrRateLimited) {
+			return nil, err
+		}
+		if err != nil || entry == nil {
+			c.reportEstimatedProgress(time.Since(start), median)
 			continue
 		}
-		var history map[string]struct {
-			Outputs map[string]struct {
-				Images []struct {
-					Filename  string `json:"filename"`
-					Subfolder string `json:"subfolder"`
-					Type      string `json:"type"`
-				} `json:"images"`
-			} `json:"outputs"`
+		// 执行异常时 ComfyUI 同样会写入 history，立即返回而不是等到超时
+		if execErr := entry.Status.ExecutionError(); execErr != nil {
+			return nil, execErr
+		}
+		if len(entry.NodeErrors) > 0 {
+			return nil, &NodeExecutionError{NodeErrors: entry.NodeErrors}
 		}
-		_ = json.NewDecoder(histResp.Body).Decode(&history)
-		histResp.Body.Close()
+		return entry, nil
+	}
+	return nil, &PollingTimeoutError{PromptID: promptID}
+}
 
-		entry, ok := history[submitResp.PromptID]
-		if !ok {
-			continue
+func historyImageURL(baseURL string, img HistoryImage) (string, error) {
+	imgType, err := ParseImageType(img.Type)
+	if err != nil {
+		return "", err
+	}
+	return ImageURL(baseURL, img.Filename, img.Subfolder, imgType), nil
+}
+
+// finishImages 拿到输出图片后的收尾：拼接 URL、按需校验、记录耗时、报告 100% 进度；
+// outputNodeID 为 imgs 的来源节点，含义同 entryImages
+func (c *Client) finishImages(ctx context.Context, promptID, outputNodeID string, imgs []HistoryImage, start time.Time) ([]string, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		imageURL, err := historyImageURL(baseURL, img)
+		if err != nil {
+			return nil, err
 		}
-		for _, out := range entry.Outputs {
-			if len(out.Images) > 0 {
-				img := out.Images[0]
-				imageURL = fmt.Sprintf("%s/view?filename=%s&subfolder=%s&type=%s",
-					baseURL, img.Filename, img.Subfolder, img.Type)
-				return imageURL, nil
-			}
+		urls = append(urls, imageURL)
+	}
+	if c.ValidateResult {
+		if err := c.validateResult(ctx, promptID, outputNodeID, urls); err != nil {
+			return nil, err
 		}
 	}
-	return "", fmt.Errorf("comfyui timeout waiting for result")
-}
-
-// buildWorkflow 与 flux.json 一致：含 BaiduTranslateNode(24) -> CLIPTextEncode(21)，其余为 Flux 文生图
-func (c *Client) buildWorkflow(prompt string, width, height, steps int, cfg float64, seed int64, baiduAppID, baiduAppKey string) map[string]interface{} {
-	// 节点 24：BaiduTranslateNode，输入为 prompt（中译英等），输出给 21
-	inputs24 := map[string]interface{}{
-		"from_translate": "auto",
-		"to_translate":   "en",
-		"text":           prompt,
-	}
-	if baiduAppID != "" && baiduAppKey != "" {
-		inputs24["baidu_appid"] = baiduAppID
-		inputs24["baidu_appkey"] = baiduAppKey
-	}
-	node24 := map[string]interface{}{
-		"inputs":     inputs24,
-		"class_type": "BaiduTranslateNode",
-	}
-
-	return map[string]interface{}{
-		"4": map[string]interface{}{
-			"inputs":     map[string]interface{}{"conditioning": []interface{}{"21", 0}},
-			"class_type": "ConditioningZeroOut",
-		},
-		"5": map[string]interface{}{
-			"inputs":     map[string]interface{}{"samples": []interface{}{"15", 0}, "vae": []interface{}{"19", 0}},
-			"class_type": "VAEDecode",
-		},
-		"8": map[string]interface{}{
-			"inputs":     map[string]interface{}{"filename_prefix": "comfy_ui_generated", "images": []interface{}{"5", 0}},
-			"class_type": "SaveImage",
-		},
-		"15": map[string]interface{}{
-			"inputs": map[string]interface{}{
-				"seed": seed, "steps": steps, "cfg": cfg,
-				"sampler_name": "euler", "scheduler": "beta", "denoise": 1,
-				"model": []interface{}{"17", 0}, "positive": []interface{}{"21", 0},
-				"negative": []interface{}{"4", 0}, "latent_image": []interface{}{"20", 0},
-			},
-			"class_type": "KSampler",
-		},
-		"17": map[string]interface{}{
-			"inputs":     map[string]interface{}{"unet_name": "flux\\flux1-dev.safetensors", "weight_dtype": "fp8_e4m3fn"},
-			"class_type": "UNETLoader",
-		},
-		"18": map[string]interface{}{
-			"inputs": map[string]interface{}{
-				"clip_name1": "flux\\t5xxl_fp8_e4m3fn.safetensors",
-				"clip_name2": "flux\\clip_l.safetensors",
-				"type":       "flux", "device": "default",
-			},
-			"class_type": "DualCLIPLoader",
-		},
-		"19": map[string]interface{}{
-			"inputs":     map[string]interface{}{"vae_name": "flux\\ae.safetensors"},
-			"class_type": "VAELoader",
-		},
-		"20": map[string]interface{}{
-			"inputs":     map[string]interface{}{"width": width, "height": height, "batch_size": 1},
-			"class_type": "EmptyLatentImage",
-		},
-		"21": map[string]interface{}{
-			"inputs":     map[string]interface{}{"text": []interface{}{"24", 0}, "clip": []interface{}{"18", 0}},
-			"class_type": "CLIPTextEncode",
-		},
-		"24": node24,
+	if err := c.checkSafety(ctx, promptID); err != nil {
+		return nil, err
+	}
+	if err := c.routeOutputs(ctx, promptID); err != nil {
+		return nil, err
+	}
+	c.durations.record(time.Since(start))
+	if c.OnProgress != nil {
+		c.OnProgress(progressTotal, progressTotal)
+	}
+	return urls, nil
+}
+
+// pollInterval 轮询 history 的默认间隔；defaultPollTimeout 默认的等待上限（轮询与 WebSocket 模式相同）
+const (
+	pollInterval       = 1 * time.Second
+	defaultPollTimeout = 300 * pollInterval
+)
+
+func (c *Client) pollEvery() time.Duration {
+	if c.PollInterval > 0 {
+		return c.PollInterval
+	}
+	return pollInterval
+}
+
+func (c *Client) pollTimeout() time.Duration {
+	if c.PollTimeout > 0 {
+		return c.PollTimeout
+	}
+	return defaultPollTimeout
+}
+
+// sleepCtx 等待 d，ctx 取消时立即返回 ctx.Err()
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
+
+func (c *Client) warnw(msg string, keysAndValues ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Warnw(msg, keysAndValues...)
+	}
+}
+
+func (c *Client) baseURL() (string, error) {
+	return normalizeBaseURL(c.BaseURL)
+}
+
+// apiClient 返回 BaseURL 上的生成客户端（见 pkg/comfyui/api），请求经 do 发出，保留鉴权、重连、缓存等处理；
+// throttled 为 true 时经 doThrottled 发出，遇到 429 按 MaxRateLimitRetries 重试
+func (c *Client) apiClient(throttled bool) (*api.Client, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	do := c.do
+	if throttled {
+		do = c.doThrottled
+	}
+	return api.NewClient(baseURL, api.WithHTTPClient(doerFunc(do)))
+}
+
+// doerFunc 把 Client.do 适配为 api.HttpRequestDoer
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+// ErrInvalidBaseURL BaseURL 为空、无法解析或不是 http / https 地址
+var ErrInvalidBaseURL = errors.New("comfyui invalid base_url")
+
+// normalizeBaseURL 去掉末尾的 /，缺少协议时补 http://（如 "comfyui:8188"）
+func normalizeBaseURL(s string) (string, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "/")
+	if s == "" {
+		return "", fmt.Errorf("%w: base_url is required", ErrInvalidBaseURL)
+	}
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidBaseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: unsupported scheme %q", ErrInvalidBaseURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%w: missing host in %q", ErrInvalidBaseURL, s)
+	}
+	return s, nil
+}
+
+// httpClient 返回 c.HTTP；未设置时返回共享的默认客户端（不回写 c.HTTP，避免并发读写）。
+// 如需自定义 c.HTTP，应在并发使用 Client 之前设置
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	c.defaultHTTPOnce.Do(func() {
+		c.defaultHTTP = &http.Client{Timeout: 30 * time.Second}
+	})
+	return c.defaultHTTP
+}
@@ -0,0 +1,595 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDataRace 多个 goroutine 并发使用 HTTP 为空的同一个 Client，需配合 go test -race 运行
+func TestDataRace(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Generate(context.Background(), &Params{Prompt: "并发测试"}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := len(srv.Prompts()); got != 8 {
+		t.Errorf("submitted %d prompts, want 8", got)
+	}
+}
+
+func TestBuildWorkflowConnections(t *testing.T) {
+	if _, err := BuildWorkflowFromParams(&Params{Prompt: "测试"}); err != nil {
+		t.Fatalf("default workflow: %v", err)
+	}
+	wf := map[string]interface{}{
+		"1": map[string]interface{}{"class_type": "VAELoader", "inputs": map[string]interface{}{"vae_name": "ae.safetensors"}},
+		"2": map[string]interface{}{"class_type": "VAEDecode", "inputs": map[string]interface{}{
+			"samples": []interface{}{"9", 0},
+			"vae":     []interface{}{"1", 1},
+		}},
+	}
+	errs := ValidateConnections(wf)
+	if len(errs) != 2 || errs[0].Reason != "source_node_missing" || errs[1].Reason != "output_index_out_of_range" {
+		t.Errorf("ValidateConnections = %+v", errs)
+	}
+}
+
+// TestWaitForImageCancel 轮询等待期间取消 ctx 应立即返回，而不是等到本轮 sleep 结束
+func TestWaitForImageCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan time.Time, 1)
+	go func() {
+		_, err := c.waitForImages(ctx, "pending", "")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+		done <- time.Now()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancelled := time.Now()
+	cancel()
+	select {
+	case returned := <-done:
+		if d := returned.Sub(cancelled); d > 10*time.Millisecond {
+			t.Errorf("waitForImages returned %v after cancel, want <= 10ms", d)
+		}
+	case <-time.After(pollInterval):
+		t.Fatal("waitForImages did not return after cancel")
+	}
+}
+
+// pendingServer 接受提交但 history 永远为空，用于测试生成被取消 / 超时
+func pendingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/prompt" {
+			w.Write([]byte(`{"prompt_id":"pending"}`))
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+}
+
+func TestGenerateCancelledContext(t *testing.T) {
+	srv := pendingServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, err := c.Generate(ctx, &Params{Prompt: "已取消"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > 10*time.Millisecond {
+		t.Errorf("Generate took %v with a cancelled context", d)
+	}
+}
+
+func TestGenerateDeadlineExceeded(t *testing.T) {
+	srv := pendingServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.Generate(ctx, &Params{Prompt: "超时"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestGenerateWithProgress(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, UseWebSocketIfAvailable: true}
+
+	var events []ProgressEvent
+	imageURL, err := c.GenerateWithProgress(context.Background(), &Params{Prompt: "进度"}, func(e ProgressEvent) {
+		events = append(events, e)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageURL == "" {
+		t.Fatal("empty image URL")
+	}
+	var sawProgress bool
+	for _, e := range events {
+		if e.Type == "progress" && e.Step == 1 && e.MaxStep == 1 {
+			sawProgress = true
+		}
+	}
+	if !sawProgress {
+		t.Errorf("events = %+v, want a progress event", events)
+	}
+}
+
+func TestGenerateWorkflow(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	wf, err := BuildWorkflowFromParams(&Params{Prompt: "自定义工作流"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	urls, err := c.GenerateWorkflow(context.Background(), wf, "8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 {
+		t.Fatalf("urls = %v, want 1 image from node 8", urls)
+	}
+}
+
+// TestSubmitWaitForResult 只凭 PromptID 就能恢复等待，对应进程重启后从任务表继续轮询
+func TestSubmitWaitForResult(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, PollInterval: 10 * time.Millisecond}
+
+	job, err := c.Submit(context.Background(), &Params{Prompt: "异步任务"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := &Client{BaseURL: srv.URL, PollInterval: 10 * time.Millisecond}
+	urls, err := resumed.WaitForResult(context.Background(), job.PromptID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 {
+		t.Fatalf("urls = %v, want 1 image", urls)
+	}
+	if status, err := resumed.Status(context.Background(), job.PromptID); err != nil || status != JobDone {
+		t.Fatalf("status = %q, %v; want done", status, err)
+	}
+	if status, _ := resumed.Status(context.Background(), "missing"); status != JobUnknown {
+		t.Fatalf("missing prompt status = %q, want unknown", status)
+	}
+}
+
+// TestDefaultWorkflowRespectsBudget 内置默认工作流路径同样经过预算检查
+func TestDefaultWorkflowRespectsBudget(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := NewClient(srv.URL, WithBudget(NewMemoryBudget(0, time.Hour)))
+
+	if _, err := c.Submit(context.Background(), &Params{Prompt: "默认工作流", UseDefaultWorkflow: true}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Submit err = %v, want ErrBudgetExceeded", err)
+	}
+	if got := len(srv.Prompts()); got != 0 {
+		t.Fatalf("submitted %d prompts, want 0", got)
+	}
+}
+
+// TestTemplateRespectsBudget Params.Template 路径同样经过预算检查，且模板不支持的 Img2Img 直接报错而不是被忽略
+func TestTemplateRespectsBudget(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := NewClient(srv.URL, WithBudget(NewMemoryBudget(0, time.Hour)))
+
+	if _, err := c.Generate(context.Background(), &Params{Prompt: "模板", Template: DefaultTemplateName}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Generate err = %v, want ErrBudgetExceeded", err)
+	}
+	if got := len(srv.Prompts()); got != 0 {
+		t.Fatalf("submitted %d prompts, want 0", got)
+	}
+
+	c = &Client{BaseURL: srv.URL}
+	p := &Params{Prompt: "模板", Template: DefaultTemplateName, Img2Img: &Img2ImgParams{Denoise: 0.5}}
+	if _, err := c.Generate(context.Background(), p); !errors.Is(err, ErrTemplateUnsupported) {
+		t.Fatalf("Img2Img with template err = %v, want ErrTemplateUnsupported", err)
+	}
+}
+
+// TestMergeModelsKeepsLoRAs 混合模型时 model1 取 LoRA 链的输出，Params.LoRAs 不会被丢弃
+func TestMergeModelsKeepsLoRAs(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	_, err := c.MergeModels(context.Background(), MergeParams{
+		Params: Params{Prompt: "混合角色", LoRAs: []LoRA{{Name: "hero.safetensors", Strength: 0.8}}},
+		ModelA: "a.safetensors", ModelB: "b.safetensors", Ratio: 0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, wf := range srv.Prompts() {
+		merge := wf["41"].(map[string]interface{})["inputs"].(map[string]interface{})
+		if model1 := merge["model1"].([]interface{}); model1[0] != "70" {
+			t.Errorf("merge model1 = %v, want LoRA node 70", model1)
+		}
+	}
+}
+
+func TestParseExecutionLog(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	var log bytes.Buffer
+	srv.LogCapture = &log
+	c := &Client{BaseURL: srv.URL}
+
+	wf, err := BuildWorkflowFromParams(&Params{Prompt: "节点耗时"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GenerateWorkflow(context.Background(), wf, ""); err != nil {
+		t.Fatal(err)
+	}
+	timings, err := ParseExecutionLog(log.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) != len(wf) {
+		t.Fatalf("timings = %v, want %d nodes", timings, len(wf))
+	}
+	if d := timings["15"]; d != 10*time.Millisecond {
+		t.Errorf("node 15 = %v, want 10ms", d)
+	}
+}
+
+func TestGenerateExecutionError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/prompt":
+			w.Write([]byte(`{"prompt_id":"failed"}`))
+		case r.URL.Path == "/history/failed":
+			w.Write([]byte(`{"failed":{"outputs":{},"status":{"status_str":"error","completed":false,"messages":[
+				["execution_start",{"prompt_id":"failed"}],
+				["execution_error",{"prompt_id":"failed","node_id":"17","node_type":"UNETLoader",
+					"exception_type":"FileNotFoundError","exception_message":"flux1-dev-fp8.safetensors not found",
+					"traceback":["Traceback (most recent call last):\n","FileNotFoundError: flux1-dev-fp8.safetensors\n"]}]]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := c.Generate(ctx, &Params{Prompt: "缺少模型"})
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("err = %v, want *ExecutionError", err)
+	}
+	if execErr.NodeID != "17" || execErr.ExceptionType != "FileNotFoundError" || len(execErr.Traceback) != 2 {
+		t.Errorf("execErr = %+v", execErr)
+	}
+}
+
+// TestMaxWorkflowSizeBytes 工作流 JSON 恰好等于上限时允许提交，多 1 字节即拒绝
+func TestMaxWorkflowSizeBytes(t *testing.T) {
+	wf := map[string]interface{}{"1": map[string]interface{}{"class_type": "Note", "inputs": map[string]interface{}{"text": "abc"}}}
+	data, err := json.Marshal(wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{MaxWorkflowSizeBytes: len(data)}
+	if _, err := c.marshalWorkflow(wf); err != nil {
+		t.Fatalf("size == limit: %v", err)
+	}
+	c.MaxWorkflowSizeBytes = len(data) - 1
+	if _, err := c.marshalWorkflow(wf); !errors.Is(err, ErrWorkflowTooLarge) {
+		t.Fatalf("size == limit+1: err = %v, want ErrWorkflowTooLarge", err)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	srv := pendingServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	errc := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.Generate(context.Background(), &Params{Prompt: "a cat"})
+			errc <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := c.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if !errors.Is(err, ErrAborted) {
+				t.Fatalf("err = %v, want ErrAborted", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Generate did not return after Abort")
+		}
+	}
+	if _, err := c.Generate(context.Background(), &Params{Prompt: "a cat"}); !errors.Is(err, ErrAborted) {
+		t.Fatalf("Generate after Abort: err = %v, want ErrAborted", err)
+	}
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://comfyui:8188/": "http://comfyui:8188",
+		"comfyui:8188":         "http://comfyui:8188",
+		"https://gpu.example/": "https://gpu.example",
+	} {
+		if got, err := normalizeBaseURL(in); err != nil || got != want {
+			t.Errorf("normalizeBaseURL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "/", "ftp://comfyui"} {
+		if _, err := normalizeBaseURL(in); !errors.Is(err, ErrInvalidBaseURL) {
+			t.Errorf("normalizeBaseURL(%q) err = %v, want ErrInvalidBaseURL", in, err)
+		}
+	}
+}
+
+// TestPoolFailover 提交返回 503 的实例换下一个实例重试，返回 400 的工作流错误直接返回
+func TestPoolFailover(t *testing.T) {
+	unavailable := NewFakeComfyUIServer()
+	defer unavailable.Close()
+	unavailable.SubmitErrorRate = 1
+	rejecting := NewFakeComfyUIServer()
+	defer rejecting.Close()
+	rejecting.RejectPrompts = true
+	healthy := NewFakeComfyUIServer()
+	defer healthy.Close()
+
+	pool := NewPool([]string{unavailable.URL, healthy.URL}, 0)
+	if _, err := pool.Generate(context.Background(), &Params{Prompt: "failover"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got := len(healthy.Prompts()); got != 1 {
+		t.Errorf("healthy backend got %d prompts, want 1", got)
+	}
+
+	pool = NewPool([]string{rejecting.URL, healthy.URL}, 0)
+	_, err := pool.Generate(context.Background(), &Params{Prompt: "invalid"})
+	var submitErr *SubmitError
+	if !errors.As(err, &submitErr) || submitErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, want 400 SubmitError", err)
+	}
+	if got := len(healthy.Prompts()); got != 1 {
+		t.Errorf("400 was retried on another backend: healthy backend got %d prompts, want 1", got)
+	}
+}
+
+// TestPoolFailoverChargesOnce 换实例重试时共享的预算只扣一次，与直接在健康实例上生成相同
+func TestPoolFailoverChargesOnce(t *testing.T) {
+	unavailable := NewFakeComfyUIServer()
+	defer unavailable.Close()
+	unavailable.SubmitErrorRate = 1
+	healthy := NewFakeComfyUIServer()
+	defer healthy.Close()
+
+	direct := NewMemoryBudget(1000, time.Hour)
+	if _, err := NewClient(healthy.URL, WithBudget(direct)).Generate(context.Background(), &Params{Prompt: "failover", Seed: 1}); err != nil {
+		t.Fatal(err)
+	}
+	shared := NewMemoryBudget(1000, time.Hour)
+	pool := NewPool([]string{unavailable.URL, healthy.URL}, 0, WithBudget(shared))
+	if _, err := pool.Generate(context.Background(), &Params{Prompt: "failover", Seed: 1}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got, want := 1000-shared.Remaining(), 1000-direct.Remaining(); got != want {
+		t.Errorf("pool consumed %.1f GPU seconds, want %.1f", got, want)
+	}
+}
+
+// TestValidateResultUsesOutputNode ValidateResult 按生成 URL 时的节点比对，而不是总取第一个输出节点
+func TestValidateResultUsesOutputNode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"p1": {"outputs": {
+			"8": {"images": [{"filename": "preview.png", "subfolder": "", "type": "temp"}]},
+			"9": {"images": [{"filename": "a.png", "subfolder": "drama", "type": "output"}, {"filename": "b.png", "subfolder": "drama", "type": "output"}]}
+		}, "status": {"status_str": "success", "completed": true}}}`))
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+	ctx := context.Background()
+
+	node9 := []string{ImageURL(srv.URL, "a.png", "drama", ImageTypeOutput), ImageURL(srv.URL, "b.png", "drama", ImageTypeOutput)}
+	if err := c.validateResult(ctx, "p1", "9", node9); err != nil {
+		t.Errorf("node 9: %v", err)
+	}
+	// 全部节点时忽略 PreviewImage 的 temp 图片，FirstImage 则会取到节点 8 的预览图
+	if err := c.validateResult(ctx, "p1", allOutputNodes, node9); err != nil {
+		t.Errorf("all nodes: %v", err)
+	}
+	preview := []string{ImageURL(srv.URL, "preview.png", "", ImageTypeTemp)}
+	if err := c.validateResult(ctx, "p1", "9", preview); !errors.Is(err, ErrResultMismatch) {
+		t.Errorf("mismatched urls: err = %v, want ErrResultMismatch", err)
+	}
+}
+
+// TestSafetyCheckFailsClosed 安全检查节点没有输出 nsfw 判定时不能当作通过
+func TestSafetyCheckFailsClosed(t *testing.T) {
+	srv := NewFakeComfyUIServer()
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	if _, err := c.Generate(context.Background(), &Params{Prompt: "安全检查", SafetyCheck: true}); !errors.Is(err, ErrSafetyCheckUnavailable) {
+		t.Fatalf("missing safety output: err = %v, want ErrSafetyCheckUnavailable", err)
+	}
+	for raw, want := range map[string]bool{
+		`{"outputs":{"80":{"nsfw":[false]}}}`: false,
+		`{"outputs":{"80":{"nsfw":true}}}`:    true,
+	} {
+		if got, err := nsfwFlagged(json.RawMessage(raw)); err != nil || got != want {
+			t.Errorf("nsfwFlagged(%s) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{`{"outputs":{"80":{}}}`, `{"outputs":{"80":{"nsfw":[]}}}`} {
+		if _, err := nsfwFlagged(json.RawMessage(raw)); !errors.Is(err, ErrSafetyCheckUnavailable) {
+			t.Errorf("nsfwFlagged(%s) err = %v, want ErrSafetyCheckUnavailable", raw, err)
+		}
+	}
+}
+
+// TestQueuePositionFirstPending 没有正在执行的任务时，排在第一位的任务仍是排队中：Status 报告 pending，Cancel 从队列删除而不是中断
+func TestQueuePositionFirstPending(t *testing.T) {
+	var mu sync.Mutex
+	deleted, interrupted := false, false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/queue" && r.Method == http.MethodPost:
+			deleted = true
+		case r.URL.Path == "/queue":
+			pending := []interface{}{}
+			if !deleted {
+				pending = append(pending, []interface{}{1, "p1", map[string]interface{}{}, map[string]interface{}{}, []string{"8"}})
+			}
+			writeJSON(w, map[string]interface{}{"queue_running": []interface{}{}, "queue_pending": pending})
+		case r.URL.Path == "/interrupt":
+			interrupted = true
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+	ctx := context.Background()
+
+	pos, running, err := c.QueuePosition(ctx, "p1")
+	if err != nil || pos != 0 || running {
+		t.Fatalf("QueuePosition = %d, %v, %v; want 0, false", pos, running, err)
+	}
+	if status, err := c.Status(ctx, "p1"); err != nil || status != JobPending {
+		t.Fatalf("Status = %q, %v; want pending", status, err)
+	}
+	if err := c.Cancel(ctx, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !deleted || interrupted {
+		t.Fatalf("deleted = %v, interrupted = %v; want delete only", deleted, interrupted)
+	}
+}
+
+// TestResultAllOutputNodes Result 与 Generate 一样返回全部输出节点的图片，而不只是第一个节点
+func TestResultAllOutputNodes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		image := func(name string) interface{} {
+			return map[string]interface{}{"images": []interface{}{map[string]interface{}{"filename": name, "subfolder": "", "type": "output"}}}
+		}
+		writeJSON(w, map[string]interface{}{"p1": map[string]interface{}{
+			"outputs": map[string]interface{}{"8": image("base.png"), "9": image("upscaled.png")},
+			"status":  map[string]interface{}{"status_str": "success", "completed": true, "messages": []interface{}{}},
+		}})
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	urls, err := c.Result(context.Background(), "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ImageURL(srv.URL, "base.png", "", ImageTypeOutput), ImageURL(srv.URL, "upscaled.png", "", ImageTypeOutput)}
+	if len(urls) != len(want) || urls[0] != want[0] || urls[1] != want[1] {
+		t.Fatalf("urls = %v, want %v", urls, want)
+	}
+}
+
+// TestResponseCache Status 查询队列不读缓存；写入新条目时清理已过期的条目
+func TestResponseCache(t *testing.T) {
+	var mu sync.Mutex
+	queued := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/queue":
+			pending := []interface{}{}
+			if queued {
+				pending = append(pending, []interface{}{1, "p1", map[string]interface{}{}, map[string]interface{}{}, []string{"8"}})
+			}
+			writeJSON(w, map[string]interface{}{"queue_running": []interface{}{}, "queue_pending": pending})
+		default:
+			writeJSON(w, map[string]interface{}{})
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, WithResponseCache(time.Hour))
+	ctx := context.Background()
+
+	if status, err := c.Status(ctx, "p1"); err != nil || status != JobPending {
+		t.Fatalf("Status = %q, %v; want pending", status, err)
+	}
+	mu.Lock()
+	queued = false
+	mu.Unlock()
+	if status, err := c.Status(ctx, "p1"); err != nil || status != JobUnknown {
+		t.Fatalf("Status after dequeue = %q, %v; want unknown (stale cache)", status, err)
+	}
+
+	cache := c.HTTP.Transport.(*cachingTransport)
+	cache.ttl = time.Nanosecond
+	for _, path := range []string{"/models/a", "/models/b", "/models/c"} {
+		resp, err := c.HTTP.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		time.Sleep(time.Millisecond)
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) != 1 {
+		t.Fatalf("cache holds %d entries, want only the latest", len(cache.entries))
+	}
+}
@@ -0,0 +1,115 @@
+package comfyui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// ImageDiff 两张图片的像素级差异
+type ImageDiff struct {
+	MeanSquaredError     float64 // RGB 三通道（0~255）的均方误差
+	PeakSNR              float64 // 单位 dB，完全相同时为 +Inf
+	StructuralSimilarity float64 // 亮度通道 8×8 窗口 SSIM 的平均值，1 表示相同
+	DiffImagePNG         []byte  // 逐像素亮度差的灰度图
+}
+
+// CompareImages 比较两张同尺寸图片，用于检测工作流或模型升级后的画面回归
+func CompareImages(a, b []byte) (*ImageDiff, error) {
+	imgA, _, err := image.Decode(bytes.NewReader(a))
+	if err != nil {
+		return nil, fmt.Errorf("comfyui decode image a: %w", err)
+	}
+	imgB, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("comfyui decode image b: %w", err)
+	}
+	ba, bb := imgA.Bounds(), imgB.Bounds()
+	if ba.Dx() != bb.Dx() || ba.Dy() != bb.Dy() {
+		return nil, fmt.Errorf("comfyui compare size mismatch: %dx%d vs %dx%d", ba.Dx(), ba.Dy(), bb.Dx(), bb.Dy())
+	}
+	w, h := ba.Dx(), ba.Dy()
+	lumaA := make([]float64, w*h)
+	lumaB := make([]float64, w*h)
+	diffImg := image.NewGray(image.Rect(0, 0, w, h))
+	var sqSum float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r1, g1, b1, _ := imgA.At(ba.Min.X+x, ba.Min.Y+y).RGBA()
+			r2, g2, b2, _ := imgB.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			for _, d := range [3]float64{
+				float64(r1>>8) - float64(r2>>8),
+				float64(g1>>8) - float64(g2>>8),
+				float64(b1>>8) - float64(b2>>8),
+			} {
+				sqSum += d * d
+			}
+			la := luma(r1, g1, b1)
+			lb := luma(r2, g2, b2)
+			lumaA[y*w+x], lumaB[y*w+x] = la, lb
+			diffImg.SetGray(x, y, color.Gray{Y: uint8(math.Min(255, math.Abs(la-lb)))})
+		}
+	}
+
+	diff := &ImageDiff{}
+	if w*h > 0 {
+		diff.MeanSquaredError = sqSum / float64(w*h*3)
+	}
+	if diff.MeanSquaredError == 0 {
+		diff.PeakSNR = math.Inf(1)
+	} else {
+		diff.PeakSNR = 10 * math.Log10(255*255/diff.MeanSquaredError)
+	}
+	diff.StructuralSimilarity = meanSSIM(lumaA, lumaB, w, h)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, diffImg); err != nil {
+		return nil, err
+	}
+	diff.DiffImagePNG = buf.Bytes()
+	return diff, nil
+}
+
+func luma(r, g, b uint32) float64 {
+	return 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+}
+
+// meanSSIM 按不重叠的 8×8 窗口计算 SSIM 并取平均
+func meanSSIM(a, b []float64, w, h int) float64 {
+	const win = 8
+	const c1 = (0.01 * 255) * (0.01 * 255)
+	const c2 = (0.03 * 255) * (0.03 * 255)
+	var total float64
+	windows := 0
+	for y0 := 0; y0 < h; y0 += win {
+		for x0 := 0; x0 < w; x0 += win {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			n := 0
+			for y := y0; y < y0+win && y < h; y++ {
+				for x := x0; x < x0+win && x < w; x++ {
+					va, vb := a[y*w+x], b[y*w+x]
+					sumA += va
+					sumB += vb
+					sumAA += va * va
+					sumBB += vb * vb
+					sumAB += va * vb
+					n++
+				}
+			}
+			fn := float64(n)
+			muA, muB := sumA/fn, sumB/fn
+			varA := sumAA/fn - muA*muA
+			varB := sumBB/fn - muB*muB
+			cov := sumAB/fn - muA*muB
+			total += ((2*muA*muB + c1) * (2*cov + c2)) / ((muA*muA + muB*muB + c1) * (varA + varB + c2))
+			windows++
+		}
+	}
+	if windows == 0 {
+		return 1
+	}
+	return total / float64(windows)
+}
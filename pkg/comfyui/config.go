@@ -0,0 +1,133 @@
+package comfyui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClientConfig Client 中可序列化的配置项，字段含义与 Client 同名字段一致
+type ClientConfig struct {
+	BaseURL                 string           `json:"base_url" yaml:"base_url"`
+	ClientID                string           `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	UseWebSocketIfAvailable *bool            `json:"use_websocket_if_available,omitempty" yaml:"use_websocket_if_available,omitempty"` // 为空时为 NewClient 的默认值 true
+	ReturnBase64            bool             `json:"return_base64,omitempty" yaml:"return_base64,omitempty"`
+	ValidateResult          bool             `json:"validate_result,omitempty" yaml:"validate_result,omitempty"`
+	EnsureModels            bool             `json:"ensure_models,omitempty" yaml:"ensure_models,omitempty"`
+	MaxRetries              int              `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	Concurrency             int              `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	PollInterval            time.Duration    `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+	PollTimeout             time.Duration    `json:"poll_timeout,omitempty" yaml:"poll_timeout,omitempty"`
+	MaxImageBytes           int64            `json:"max_image_bytes,omitempty" yaml:"max_image_bytes,omitempty"`
+	FilenameStrategy        FilenameStrategy `json:"filename_strategy,omitempty" yaml:"filename_strategy,omitempty"`
+	OutputDatePrefix        bool             `json:"output_date_prefix,omitempty" yaml:"output_date_prefix,omitempty"`
+	ErrorLocale             string           `json:"error_locale,omitempty" yaml:"error_locale,omitempty"`
+}
+
+// WorkflowConfig 打包 Client 配置、默认参数与命名参数预设，对应平台 ConfigMap 中的一份 YAML
+type WorkflowConfig struct {
+	Client ClientConfig `json:"client" yaml:"client"`
+	// DefaultParams 每次 Generate 的基础参数，调用方 Params 中的零值字段由它补齐
+	DefaultParams Params `json:"default_params" yaml:"default_params"`
+	// Presets 命名参数预设，通过 Client.ParamsPreset 读取
+	Presets map[string]*Params `json:"presets,omitempty" yaml:"presets,omitempty"`
+	// Templates 命名工作流模板，Params.Template 按名称引用
+	Templates map[string]TemplateConfig `json:"templates,omitempty" yaml:"templates,omitempty"`
+}
+
+// LoadConfig 读取 YAML 格式的 WorkflowConfig，未知字段视为错误
+func LoadConfig(path string) (*WorkflowConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg WorkflowConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("comfyui parse config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// SaveConfig 把 cfg 写为 YAML
+func SaveConfig(path string, cfg *WorkflowConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("comfyui encode config: %w", err)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// NewClientFromConfig 从 YAML 配置创建 Client，opts 在配置项之后应用；DefaultParams 作为每次 Generate 的基础参数
+func NewClientFromConfig(path string, opts ...Option) (*Client, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	cc := cfg.Client
+	c := NewClient(cc.BaseURL)
+	c.ClientID = cc.ClientID
+	if cc.UseWebSocketIfAvailable != nil {
+		c.UseWebSocketIfAvailable = *cc.UseWebSocketIfAvailable
+	}
+	c.ReturnBase64 = cc.ReturnBase64
+	c.ValidateResult = cc.ValidateResult
+	c.EnsureModels = cc.EnsureModels
+	c.MaxRetries = cc.MaxRetries
+	c.Concurrency = cc.Concurrency
+	c.PollInterval = cc.PollInterval
+	c.PollTimeout = cc.PollTimeout
+	c.MaxImageBytes = cc.MaxImageBytes
+	c.FilenameStrategy = cc.FilenameStrategy
+	c.OutputDatePrefix = cc.OutputDatePrefix
+	c.ErrorLocale = cc.ErrorLocale
+	c.defaultParams = &cfg.DefaultParams
+	c.paramsPresets = cfg.Presets
+	if len(cfg.Templates) > 0 {
+		if c.templates, err = loadTemplates(filepath.Dir(path), cfg.Templates); err != nil {
+			return nil, err
+		}
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// ParamsPreset 返回配置中名为 name 的参数预设（已用 DefaultParams 补齐的副本）
+func (c *Client) ParamsPreset(name string) (*Params, bool) {
+	preset, ok := c.paramsPresets[name]
+	if !ok || preset == nil {
+		return nil, false
+	}
+	p := *preset
+	c.applyDefaultParams(&p)
+	return &p, true
+}
+
+// applyDefaultParams 用 Client 的 DefaultParams 补齐 p 中的零值字段（内嵌的 SamplerConfig 逐字段补齐）
+func (c *Client) applyDefaultParams(p *Params) {
+	if c.defaultParams == nil {
+		return
+	}
+	mergeZeroFields(reflect.ValueOf(p).Elem(), reflect.ValueOf(c.defaultParams).Elem())
+}
+
+func mergeZeroFields(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		if dst.Type().Field(i).Anonymous && field.Kind() == reflect.Struct {
+			mergeZeroFields(field, src.Field(i))
+			continue
+		}
+		if field.IsZero() {
+			field.Set(src.Field(i))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+)
+
+// ConnectionState 与 ComfyUI 的连接状态，由 HTTP 请求的成败驱动
+type ConnectionState int32
+
+const (
+	StateDisconnected ConnectionState = iota // 尚未请求过，或最近一次请求网络失败
+	StateConnecting                          // 断开后的首个请求进行中
+	StateConnected                           // 最近一次请求收到了响应（无论状态码）
+	StateReconnecting                        // AutoReconnect 退避重连中
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	}
+	return "unknown"
+}
+
+// State 返回当前连接状态
+func (c *Client) State() ConnectionState {
+	return ConnectionState(c.connState.Load())
+}
+
+// setState 切换状态，状态变化时回调 OnStateChange
+func (c *Client) setState(s ConnectionState) {
+	old := ConnectionState(c.connState.Swap(int32(s)))
+	if old != s && c.OnStateChange != nil {
+		c.OnStateChange(old, s)
+	}
+}
+
+// beginRequest 断开状态下发起请求时进入 connecting
+func (c *Client) beginRequest() {
+	if c.State() == StateDisconnected {
+		c.setState(StateConnecting)
+	}
+}
+
+// endRequest 按请求结果切换状态；调用方取消或超时不代表连接断开，仅把 connecting 退回 disconnected
+func (c *Client) endRequest(err error) {
+	switch {
+	case err == nil:
+		c.setState(StateConnected)
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		if c.State() == StateConnecting {
+			c.setState(StateDisconnected)
+		}
+	default:
+		c.setState(StateDisconnected)
+	}
+}
@@ -0,0 +1,89 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ConnectionError 工作流中一条无效的连线
+type ConnectionError struct {
+	SourceNodeID string
+	TargetNodeID string
+	InputName    string
+	Reason       string // source_node_missing / output_index_out_of_range
+}
+
+func (e ConnectionError) Error() string {
+	return fmt.Sprintf("node %s input %q -> node %s: %s", e.TargetNodeID, e.InputName, e.SourceNodeID, e.Reason)
+}
+
+// ValidateConnections 检查所有 ["节点ID", 输出序号] 连线：源节点必须存在；
+// 源节点类型在 builtinNodeSchemas 中有定义时，输出序号不得越界
+func ValidateConnections(wf map[string]interface{}) []ConnectionError {
+	ids := make([]string, 0, len(wf))
+	for id := range wf {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []ConnectionError
+	for _, targetID := range ids {
+		node, _ := wf[targetID].(map[string]interface{})
+		inputs, _ := node["inputs"].(map[string]interface{})
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			srcID, idx, ok := parseWire(inputs[name])
+			if !ok {
+				continue
+			}
+			src, exists := wf[srcID].(map[string]interface{})
+			if !exists {
+				errs = append(errs, ConnectionError{SourceNodeID: srcID, TargetNodeID: targetID, InputName: name, Reason: "source_node_missing"})
+				continue
+			}
+			classType, _ := src["class_type"].(string)
+			if schema, known := builtinNodeSchemas[classType]; known && (idx < 0 || idx >= len(schema.Outputs)) {
+				errs = append(errs, ConnectionError{SourceNodeID: srcID, TargetNodeID: targetID, InputName: name, Reason: "output_index_out_of_range"})
+			}
+		}
+	}
+	return errs
+}
+
+// parseWire 识别连线值，序号既可能是 Go 构造的 int，也可能是 JSON 解码出的 float64
+func parseWire(v interface{}) (string, int, bool) {
+	wire, ok := v.([]interface{})
+	if !ok || len(wire) != 2 {
+		return "", 0, false
+	}
+	id, ok := wire[0].(string)
+	if !ok {
+		return "", 0, false
+	}
+	switch n := wire[1].(type) {
+	case int:
+		return id, n, true
+	case float64:
+		return id, int(n), true
+	}
+	return "", 0, false
+}
+
+// BuildWorkflowFromParams 填充默认参数并构建 flux.json 工作流，同时校验节点连线
+func BuildWorkflowFromParams(p *Params) (map[string]interface{}, error) {
+	applyDefaults(p)
+	wf := (&Client{}).buildWorkflow(p)
+	if connErrs := ValidateConnections(wf); len(connErrs) > 0 {
+		errs := make([]error, len(connErrs))
+		for i, e := range connErrs {
+			errs[i] = e
+		}
+		return nil, fmt.Errorf("comfyui invalid workflow: %w", errors.Join(errs...))
+	}
+	return wf, nil
+}
@@ -0,0 +1,65 @@
+package comfyui
+
+import (
+	"context"
+	"time"
+)
+
+// CalibrationResult 实测的采样速度，用于按截止时间估算可用步数
+type CalibrationResult struct {
+	// SecondsPerStep 在 Megapixels 分辨率下每步采样耗时
+	SecondsPerStep float64
+	Megapixels     float64
+}
+
+// deadlineAdaptation 见 WithDeadlineAdaptation
+type deadlineAdaptation struct {
+	minSteps    int
+	targetSteps int
+}
+
+// WithDeadlineAdaptation ctx 带截止时间时按剩余时间调整步数：时间充足用 targetQuality 步（<=0 时沿用 Params.Steps），
+// 不足时降到可完成的步数，但不少于 minSteps；每步耗时取 Client.Calibration，未配置时按 0.5 秒/步/百万像素估算
+func WithDeadlineAdaptation(minSteps, targetQuality int) Option {
+	return func(c *Client) {
+		c.deadline = &deadlineAdaptation{minSteps: minSteps, targetSteps: targetQuality}
+	}
+}
+
+// secondsPerStep 按分辨率缩放校准结果
+func (c *Client) secondsPerStep(p *Params) float64 {
+	megapixels := float64(p.Width*p.Height) / 1e6
+	if cal := c.Calibration; cal != nil && cal.SecondsPerStep > 0 && cal.Megapixels > 0 {
+		return cal.SecondsPerStep * megapixels / cal.Megapixels
+	}
+	// 与 estimateGPUSeconds 的估算一致
+	return megapixels * 0.5
+}
+
+// adaptStepsToDeadline 在构建工作流前按 ctx 的剩余时间修改 p.Steps
+func (c *Client) adaptStepsToDeadline(ctx context.Context, p *Params) {
+	if c.deadline == nil {
+		return
+	}
+	if c.deadline.targetSteps > 0 {
+		p.Steps = c.deadline.targetSteps
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	perStep := c.secondsPerStep(p)
+	if perStep <= 0 {
+		return
+	}
+	affordable := int(time.Until(deadline).Seconds() / perStep)
+	if affordable >= p.Steps {
+		return
+	}
+	steps := affordable
+	if steps < c.deadline.minSteps {
+		steps = c.deadline.minSteps
+	}
+	c.warnw("ComfyUI reducing steps to meet deadline", "steps", p.Steps, "reduced_to", steps, "remaining", time.Until(deadline).String())
+	p.Steps = steps
+}
@@ -0,0 +1,92 @@
+package comfyui
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultWorkflowJSON 内置的 Flux 工作流模板，更新该文件即可修改 Params.UseDefaultWorkflow 使用的默认工作流
+//
+//go:embed defaults/flux_workflow.json
+var defaultWorkflowJSON []byte
+
+// loadDefaultWorkflow 解析内置工作流模板，每次返回新的副本
+func loadDefaultWorkflow() (map[string]interface{}, error) {
+	var wf map[string]interface{}
+	if err := json.Unmarshal(defaultWorkflowJSON, &wf); err != nil {
+		return nil, fmt.Errorf("comfyui decode default workflow: %w", err)
+	}
+	return wf, nil
+}
+
+// workflowVariables 模板中可用的变量：{{seed}} {{width}} {{height}} {{steps}} {{cfg}} {{prompt}}
+func workflowVariables(p *Params) map[string]interface{} {
+	return map[string]interface{}{
+		"seed":   p.Seed,
+		"width":  p.Width,
+		"height": p.Height,
+		"steps":  p.Steps,
+		"cfg":    p.CFG,
+		"prompt": p.Prompt,
+	}
+}
+
+// substituteWorkflowVars 递归替换字符串中的 {{name}}：整个字符串就是一个变量时保留变量的类型（数字仍是数字），
+// 否则按文本拼接；未知变量原样保留
+func substituteWorkflowVars(v interface{}, vars map[string]interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = substituteWorkflowVars(item, vars)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = substituteWorkflowVars(item, vars)
+		}
+		return out
+	case string:
+		if strings.HasPrefix(val, "{{") && strings.HasSuffix(val, "}}") {
+			if value, ok := vars[strings.TrimSpace(val[2:len(val)-2])]; ok {
+				return value
+			}
+		}
+		for name, value := range vars {
+			val = strings.ReplaceAll(val, "{{"+name+"}}", fmt.Sprint(value))
+		}
+		return val
+	}
+	return v
+}
+
+// templateWorkflow Params.WorkflowOverride 非空时取它，否则在 UseDefaultWorkflow 为 true 时取内置模板，并代入变量；
+// 两者都未设置时返回 nil
+func templateWorkflow(p *Params) (map[string]interface{}, error) {
+	wf := p.WorkflowOverride
+	if len(wf) == 0 {
+		if !p.UseDefaultWorkflow {
+			return nil, nil
+		}
+		var err error
+		if wf, err = loadDefaultWorkflow(); err != nil {
+			return nil, err
+		}
+	}
+	return substituteWorkflowVars(wf, workflowVariables(p)).(map[string]interface{}), nil
+}
+
+// GenerateFromWorkflow 提交完整的 API 格式工作流并返回第一张输出图片的 URL
+func (c *Client) GenerateFromWorkflow(ctx context.Context, workflow map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	ctx, cancel := c.withAbort(ctx)
+	defer cancel()
+	imageURL, err := c.execute(ctx, workflow, nil)
+	return imageURL, c.localizeError(c.abortErr(err))
+}
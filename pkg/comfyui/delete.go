@@ -0,0 +1,90 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrDeleteUnsupported ComfyUI 的 HTTP API 没有删除文件的接口，未配置 Client.OutputDir 时无法删除输出文件
+var ErrDeleteUnsupported = errors.New("comfyui cannot delete output files without Client.OutputDir")
+
+// DeleteOutputFiles 并发删除 Client.OutputDir 下的输出文件，filename 可带子目录（如 "drama/a.png"）；
+// 返回成功删除的数量及各失败文件的错误，未配置 OutputDir 时每个文件都返回 ErrDeleteUnsupported
+func (c *Client) DeleteOutputFiles(ctx context.Context, filenames []string, concurrency int) (deleted int, errs []error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, name := range filenames {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("comfyui delete %s: %w", name, ctx.Err()))
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			subfolder, filename := "", name
+			if i := strings.LastIndex(name, "/"); i >= 0 {
+				subfolder, filename = name[:i], name[i+1:]
+			}
+			err := c.deleteViewFile(ctx, filename, subfolder, ImageTypeOutput)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			deleted++
+		}(name)
+	}
+	wg.Wait()
+	return deleted, errs
+}
+
+// deleteViewFile 删除 OutputDir 下的单个文件；只支持 output 类型，文件不存在同样视为错误
+func (c *Client) deleteViewFile(ctx context.Context, filename, subfolder string, t ImageType) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("comfyui delete %s: %w", filename, err)
+	}
+	if c.OutputDir == "" || t != ImageTypeOutput {
+		return fmt.Errorf("%w: %s (type %s)", ErrDeleteUnsupported, filename, t)
+	}
+	// filename / subfolder 来自 history 或调用方，不允许通过 ".." 跳出 OutputDir
+	rel := filepath.Join(filepath.FromSlash(subfolder), filepath.FromSlash(filename))
+	if filename == "" || !filepath.IsLocal(rel) {
+		return fmt.Errorf("comfyui delete %s: path escapes OutputDir", filename)
+	}
+	if err := os.Remove(filepath.Join(c.OutputDir, rel)); err != nil {
+		return fmt.Errorf("comfyui delete %s: %w", filename, err)
+	}
+	return nil
+}
+
+// deleteImageURL 按 /view 地址删除对应文件
+func (c *Client) deleteImageURL(ctx context.Context, imageURL string) error {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	t, err := ParseImageType(q.Get("type"))
+	if err != nil {
+		return err
+	}
+	return c.deleteViewFile(ctx, q.Get("filename"), q.Get("subfolder"), t)
+}
@@ -0,0 +1,111 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// DiagnosticsReport 客户端配置与服务器能力的汇总，提交问题时附上 JSON 即可；各项查询失败时对应的 *Error 字段有值
+type DiagnosticsReport struct {
+	ClientVersion string `json:"client_version"`
+	BaseURL       string `json:"base_url"`
+	Config        struct {
+		UseWebSocketIfAvailable bool   `json:"use_websocket_if_available"`
+		MaxRetries              int    `json:"max_retries"`
+		Concurrency             int    `json:"concurrency"`
+		MaxWorkflowSizeBytes    int    `json:"max_workflow_size_bytes"`
+		EnsureModels            bool   `json:"ensure_models"`
+		ErrorLocale             string `json:"error_locale,omitempty"`
+	} `json:"config"`
+
+	Healthy     bool   `json:"healthy"`
+	HealthError string `json:"health_error,omitempty"`
+
+	ServerVersion string          `json:"server_version,omitempty"`
+	Features      map[string]bool `json:"features,omitempty"`
+	VersionError  string          `json:"version_error,omitempty"`
+
+	SystemStats      *SystemStats `json:"system_stats,omitempty"`
+	SystemStatsError string       `json:"system_stats_error,omitempty"`
+
+	// Models 模型目录 -> 文件名
+	Models      map[string][]string `json:"models,omitempty"`
+	ModelErrors map[string]string   `json:"model_errors,omitempty"`
+
+	Queue      *QueueInfo `json:"queue,omitempty"`
+	QueueError string     `json:"queue_error,omitempty"`
+}
+
+// JSON 缩进格式的报告
+func (r *DiagnosticsReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// GenerateDiagnosticsReport 依次执行 HealthCheck、GetSystemStats、ListModels（加载器用到的各目录）、QueueStatus、CheckAPIVersion；
+// 单项失败不会中断，只有 BaseURL 无效时返回错误
+func (c *Client) GenerateDiagnosticsReport(ctx context.Context) (*DiagnosticsReport, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	r := &DiagnosticsReport{ClientVersion: Version, BaseURL: baseURL}
+	r.Config.UseWebSocketIfAvailable = c.UseWebSocketIfAvailable
+	r.Config.MaxRetries = c.MaxRetries
+	r.Config.Concurrency = c.Concurrency
+	r.Config.MaxWorkflowSizeBytes = c.MaxWorkflowSizeBytes
+	r.Config.EnsureModels = c.EnsureModels
+	r.Config.ErrorLocale = c.ErrorLocale
+
+	if err := c.HealthCheck(ctx); err != nil {
+		r.HealthError = err.Error()
+	} else {
+		r.Healthy = true
+	}
+	if stats, err := c.GetSystemStats(ctx); err != nil {
+		r.SystemStatsError = err.Error()
+	} else {
+		r.SystemStats = stats
+	}
+	for _, folder := range modelFolders() {
+		names, err := c.ListModels(ctx, folder)
+		if err != nil {
+			if r.ModelErrors == nil {
+				r.ModelErrors = map[string]string{}
+			}
+			r.ModelErrors[folder] = err.Error()
+			continue
+		}
+		if r.Models == nil {
+			r.Models = map[string][]string{}
+		}
+		r.Models[folder] = names
+	}
+	if queue, err := c.QueueStatus(ctx); err != nil {
+		r.QueueError = err.Error()
+	} else {
+		r.Queue = queue
+	}
+	if version, features, err := c.CheckAPIVersion(ctx); err != nil {
+		r.VersionError = err.Error()
+	} else {
+		r.ServerVersion, r.Features = version, features
+	}
+	return r, nil
+}
+
+// modelFolders modelLoaderInputs 中出现的模型目录，去重排序
+func modelFolders() []string {
+	seen := map[string]bool{}
+	for _, inputs := range modelLoaderInputs {
+		for _, folder := range inputs {
+			seen[folder] = true
+		}
+	}
+	folders := make([]string, 0, len(seen))
+	for f := range seen {
+		folders = append(folders, f)
+	}
+	sort.Strings(folders)
+	return folders
+}
@@ -0,0 +1,123 @@
+package comfyui
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// NodeChange 节点级变化类型
+type NodeChange string
+
+const (
+	NodeAdded    NodeChange = "added"
+	NodeRemoved  NodeChange = "removed"
+	NodeModified NodeChange = "modified"
+)
+
+// InputChange 节点单个输入的变化，新增 / 删除的输入对应一侧为 nil
+type InputChange struct {
+	Name string
+	Old  interface{}
+	New  interface{}
+}
+
+// NodeDiff 一个节点的变化
+type NodeDiff struct {
+	NodeID    string
+	ClassType string
+	Change    NodeChange
+	Inputs    []InputChange // 仅 NodeModified 时非空
+}
+
+// String 输出可读描述，如 "KSampler.steps changed from 25 to 30 (node 3)"，多个输入变化各占一行
+func (d NodeDiff) String() string {
+	if d.Change != NodeModified {
+		return fmt.Sprintf("%s %s (node %s)", d.ClassType, d.Change, d.NodeID)
+	}
+	lines := make([]string, len(d.Inputs))
+	for i, in := range d.Inputs {
+		lines[i] = fmt.Sprintf("%s.%s changed from %v to %v (node %s)", d.ClassType, in.Name, in.Old, in.New, d.NodeID)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiffWorkflows 按节点 ID 比较两个 API 格式工作流，返回新增、删除及输入有变化的节点（按节点 ID 排序）
+func DiffWorkflows(a, b map[string]interface{}) []NodeDiff {
+	ids := map[string]bool{}
+	for id := range a {
+		ids[id] = true
+	}
+	for id := range b {
+		ids[id] = true
+	}
+	nodes := make(map[string]map[string]interface{}, len(ids))
+	for id := range ids {
+		nodes[id] = nil
+	}
+
+	var diffs []NodeDiff
+	for _, id := range sortedNodeIDs(nodes) {
+		oldNode, inOld := a[id].(map[string]interface{})
+		newNode, inNew := b[id].(map[string]interface{})
+		switch {
+		case !inOld:
+			diffs = append(diffs, NodeDiff{NodeID: id, ClassType: classTypeOf(newNode), Change: NodeAdded})
+		case !inNew:
+			diffs = append(diffs, NodeDiff{NodeID: id, ClassType: classTypeOf(oldNode), Change: NodeRemoved})
+		default:
+			if changes := diffInputs(oldNode, newNode); len(changes) > 0 {
+				diffs = append(diffs, NodeDiff{NodeID: id, ClassType: classTypeOf(newNode), Change: NodeModified, Inputs: changes})
+			}
+		}
+	}
+	return diffs
+}
+
+// BuildWorkflowDiff 分别用两组参数构建内置工作流并比较；SeedMode 为 random 的两侧种子必然不同
+func BuildWorkflowDiff(oldParams, newParams *Params) ([]NodeDiff, error) {
+	oldCopy, newCopy := *oldParams, *newParams
+	a, err := BuildWorkflowFromParams(&oldCopy)
+	if err != nil {
+		return nil, err
+	}
+	b, err := BuildWorkflowFromParams(&newCopy)
+	if err != nil {
+		return nil, err
+	}
+	return DiffWorkflows(a, b), nil
+}
+
+func classTypeOf(node map[string]interface{}) string {
+	classType, _ := node["class_type"].(string)
+	return classType
+}
+
+func diffInputs(oldNode, newNode map[string]interface{}) []InputChange {
+	oldInputs, _ := oldNode["inputs"].(map[string]interface{})
+	newInputs, _ := newNode["inputs"].(map[string]interface{})
+	names := map[string]bool{}
+	for name := range oldInputs {
+		names[name] = true
+	}
+	for name := range newInputs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []InputChange
+	if oldNodeClass, newNodeClass := classTypeOf(oldNode), classTypeOf(newNode); oldNodeClass != newNodeClass {
+		changes = append(changes, InputChange{Name: "class_type", Old: oldNodeClass, New: newNodeClass})
+	}
+	for _, name := range sorted {
+		if !reflect.DeepEqual(oldInputs[name], newInputs[name]) {
+			changes = append(changes, InputChange{Name: name, Old: oldInputs[name], New: newInputs[name]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,124 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// defaultMaxImageBytes DownloadImage 默认的响应体上限
+const defaultMaxImageBytes = 50 << 20
+
+var (
+	// ErrHostMismatch 图片地址不属于 Client.BaseURL 所在的 ComfyUI 服务器
+	ErrHostMismatch = errors.New("comfyui image url host does not match base_url")
+	// ErrImageTooLarge 图片超过 Client.MaxImageBytes
+	ErrImageTooLarge = errors.New("comfyui image exceeds max size")
+)
+
+// DownloadImage 下载 Generate 返回的图片地址，返回内容及响应的 Content-Type；
+// 只允许访问 BaseURL 所在主机，避免把任意 URL 交给服务端请求。
+// 配置了 WithThumbnail 时返回缩略图（PNG），优先从 ThumbnailCache 读取
+func (c *Client) DownloadImage(ctx context.Context, imageURL string) ([]byte, string, error) {
+	if c.thumbnail == nil {
+		return c.downloadImage(ctx, imageURL)
+	}
+	if thumb, ok := c.thumbnail.cache.Get(imageURL); ok {
+		return thumb, "image/png", nil
+	}
+	data, _, err := c.downloadImage(ctx, imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+	thumb, err := makeThumbnail(data, c.thumbnail.maxSize)
+	if err != nil {
+		return nil, "", err
+	}
+	c.thumbnail.cache.Set(imageURL, thumb)
+	return thumb, "image/png", nil
+}
+
+// downloadImage DownloadImage 的实现，始终返回原图
+func (c *Client) downloadImage(ctx context.Context, imageURL string) ([]byte, string, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, "", err
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("comfyui parse base_url: %w", err)
+	}
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("comfyui parse image url: %w", err)
+	}
+	if u.Scheme != base.Scheme || !strings.EqualFold(u.Host, base.Host) {
+		return nil, "", fmt.Errorf("%w: %s", ErrHostMismatch, u.Host)
+	}
+
+	resp, err := c.getFile(ctx, u.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("comfyui download: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, "", fmt.Errorf("comfyui download %s: %s", resp.Status, string(b))
+	}
+
+	limit := c.MaxImageBytes
+	if limit <= 0 {
+		limit = defaultMaxImageBytes
+	}
+	// 多读 1 字节以区分“正好等于上限”和“超出上限”
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("comfyui download: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, limit)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// getFile GET fileURL；BaseURL 下的 /view 地址经生成客户端的 GetView 发出，其余地址直接请求
+func (c *Client) getFile(ctx context.Context, fileURL string) (*http.Response, error) {
+	if params, ok := c.viewParams(fileURL); ok {
+		cl, err := c.apiClient(false)
+		if err != nil {
+			return nil, err
+		}
+		return cl.GetView(ctx, params)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// viewParams 解析 ImageURL 生成的 /view 地址；带有 filename / subfolder / type 以外参数的地址不解析，避免丢失参数
+func (c *Client) viewParams(fileURL string) (*api.GetViewParams, bool) {
+	baseURL, err := c.baseURL()
+	if err != nil || !strings.HasPrefix(fileURL, baseURL+"/view?") {
+		return nil, false
+	}
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, false
+	}
+	q := u.Query()
+	for key := range q {
+		if key != "filename" && key != "subfolder" && key != "type" {
+			return nil, false
+		}
+	}
+	subfolder, imageType := q.Get("subfolder"), api.GetViewParamsType(q.Get("type"))
+	return &api.GetViewParams{Filename: q.Get("filename"), Subfolder: &subfolder, Type: &imageType}, true
+}
@@ -0,0 +1,79 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DryRunResult Params.DryRun 时 Generate 只校验不执行，结果在 GenerateResult.DryRun 中
+type DryRunResult struct {
+	Valid  bool
+	Errors []DryRunError
+}
+
+// DryRunError 校验未通过的节点
+type DryRunError struct {
+	NodeID    string
+	ClassType string
+	Message   string
+}
+
+// dryRun 构建工作流并对照 GET /object_info 在本地校验，不向 /prompt 提交：
+// ComfyUI 的 /prompt 没有只校验不执行的参数，提交即会排队执行
+func (c *Client) dryRun(ctx context.Context, p *Params) (*DryRunResult, error) {
+	workflow, err := c.prepareWorkflow(ctx, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	var info map[string]nodeInfo
+	found, err := c.getJSON(ctx, baseURL+"/object_info", &info)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui dry run object_info: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("comfyui dry run: GET /object_info not found")
+	}
+	errs := validateWorkflow(workflow, info)
+	return &DryRunResult{Valid: len(errs) == 0, Errors: errs}, nil
+}
+
+// compareVersions 比较 "0.3.10" 形式的版本号，忽略前缀 v 与非数字后缀
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.Atoi(s[:end])
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,36 @@
+package comfyui
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nodeTimingLine 控制台日志中的单节点耗时行，如 "15: 3.42s"
+var nodeTimingLine = regexp.MustCompile(`^\s*([0-9A-Za-z_:.-]+?):\s+([0-9]+(?:\.[0-9]+)?)s\s*$`)
+
+// ParseExecutionLog 解析 ComfyUI 控制台日志中的 "节点ID: N.NNs" 耗时行，返回节点 ID -> 耗时；
+// 其它行忽略，同一节点多次出现（多个 prompt）时累加
+func ParseExecutionLog(log string) (map[string]time.Duration, error) {
+	timings := make(map[string]time.Duration)
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		m := nodeTimingLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		secs, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("comfyui parse node %s duration: %w", m[1], err)
+		}
+		timings[m[1]] += time.Duration(secs * float64(time.Second))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("comfyui read execution log: %w", err)
+	}
+	return timings, nil
+}
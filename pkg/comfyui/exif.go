@@ -0,0 +1,100 @@
+package comfyui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// GPSCoords 十进制度数表示的经纬度，南纬 / 西经为负
+type GPSCoords struct {
+	Latitude  float64 `json:"latitude" yaml:"latitude"`
+	Longitude float64 `json:"longitude" yaml:"longitude"`
+}
+
+// EXIF（TIFF）字段类型与 GPS 标签
+const (
+	exifTypeByte     = 1
+	exifTypeASCII    = 2
+	exifTypeLong     = 4
+	exifTypeRational = 5
+
+	exifTagGPSInfo      = 0x8825
+	gpsTagVersionID     = 0x0000
+	gpsTagLatitudeRef   = 0x0001
+	gpsTagLatitude      = 0x0002
+	gpsTagLongitudeRef  = 0x0003
+	gpsTagLongitude     = 0x0004
+	gpsSecondsPrecision = 10000
+)
+
+// EmbedPNGGPS 在 IHDR 之后插入只含 GPS IFD 的 eXIf 块（PNG 1.5 起支持，Exiftool / 相册可直接读取）
+func EmbedPNGGPS(data []byte, coords GPSCoords) ([]byte, error) {
+	return insertPNGChunk(data, "eXIf", buildGPSExif(coords))
+}
+
+// buildGPSExif 生成大端 TIFF 结构：IFD0 只有指向 GPS IFD 的 GPSInfo，GPS IFD 含版本、经纬度及其方向
+func buildGPSExif(coords GPSCoords) []byte {
+	const (
+		ifd0Offset = 8
+		gpsOffset  = ifd0Offset + 2 + 12 + 4
+		latOffset  = gpsOffset + 2 + 5*12 + 4
+		lonOffset  = latOffset + 3*8
+	)
+	latRef, lonRef := "N", "E"
+	if coords.Latitude < 0 {
+		latRef = "S"
+	}
+	if coords.Longitude < 0 {
+		lonRef = "W"
+	}
+
+	var buf bytes.Buffer
+	w := func(v interface{}) { _ = binary.Write(&buf, binary.BigEndian, v) }
+	entry := func(tag, typ uint16, count uint32, value [4]byte) {
+		w(tag)
+		w(typ)
+		w(count)
+		buf.Write(value[:])
+	}
+	offset := func(v uint32) (b [4]byte) {
+		binary.BigEndian.PutUint32(b[:], v)
+		return b
+	}
+	ascii := func(s string) (b [4]byte) {
+		copy(b[:], s)
+		return b
+	}
+
+	buf.WriteString("MM")
+	w(uint16(42))
+	w(uint32(ifd0Offset))
+
+	w(uint16(1))
+	entry(exifTagGPSInfo, exifTypeLong, 1, offset(gpsOffset))
+	w(uint32(0))
+
+	w(uint16(5))
+	entry(gpsTagVersionID, exifTypeByte, 4, [4]byte{2, 2, 0, 0})
+	entry(gpsTagLatitudeRef, exifTypeASCII, 2, ascii(latRef))
+	entry(gpsTagLatitude, exifTypeRational, 3, offset(latOffset))
+	entry(gpsTagLongitudeRef, exifTypeASCII, 2, ascii(lonRef))
+	entry(gpsTagLongitude, exifTypeRational, 3, offset(lonOffset))
+	w(uint32(0))
+
+	for _, v := range []float64{coords.Latitude, coords.Longitude} {
+		for _, r := range degreesToDMS(math.Abs(v)) {
+			w(r)
+		}
+	}
+	return buf.Bytes()
+}
+
+// degreesToDMS 把十进制度数拆成度、分、秒三个 RATIONAL（分子、分母），秒保留 4 位小数
+func degreesToDMS(deg float64) [3][2]uint32 {
+	total := uint64(math.Round(deg * 3600 * gpsSecondsPrecision))
+	secs := total % (60 * gpsSecondsPrecision)
+	mins := total / (60 * gpsSecondsPrecision) % 60
+	degs := total / (3600 * gpsSecondsPrecision)
+	return [3][2]uint32{{uint32(degs), 1}, {uint32(mins), 1}, {uint32(secs), gpsSecondsPrecision}}
+}
@@ -0,0 +1,87 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrManagerUnavailable 服务器未安装 ComfyUI-Manager（或其安全级别禁止通过 API 安装），无法自动安装扩展
+var ErrManagerUnavailable = errors.New("comfyui manager install api is unavailable")
+
+// EnsureExtension 通过 GET /extensions 检查 extensionGitURL 对应的扩展是否已加载，未加载时调用
+// ComfyUI-Manager 的 /customnode/install/git_url 安装；安装后需重启 ComfyUI 才会生效。
+// 只想查看缺少哪些扩展时用 DryRunInstall
+func (c *Client) EnsureExtension(ctx context.Context, extensionGitURL string) error {
+	missing, err := c.DryRunInstall(ctx, extensionGitURL)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/customnode/install/git_url?url="+url.QueryEscape(extensionGitURL), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("comfyui install extension: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrManagerUnavailable, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("comfyui install extension %s: %s", resp.Status, string(b))
+	}
+	c.warnw("ComfyUI extension installed, restart required", "url", extensionGitURL)
+	return nil
+}
+
+// DryRunInstall 只检查不安装：返回 extensionGitURLs 中尚未加载、EnsureExtension 会去安装的扩展
+func (c *Client) DryRunInstall(ctx context.Context, extensionGitURLs ...string) ([]string, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	var loaded []string
+	found, err := c.getJSON(ctx, baseURL+"/extensions", &loaded)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui list extensions: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("comfyui list extensions: /extensions not found")
+	}
+	var missing []string
+	for _, gitURL := range extensionGitURLs {
+		if !extensionLoaded(loaded, extensionRepoName(gitURL)) {
+			missing = append(missing, gitURL)
+		}
+	}
+	return missing, nil
+}
+
+// extensionRepoName 仓库名即扩展在 custom_nodes 下的目录名，如 https://github.com/ltdrdata/ComfyUI-Manager.git -> ComfyUI-Manager
+func extensionRepoName(gitURL string) string {
+	return strings.TrimSuffix(path.Base(strings.TrimRight(gitURL, "/")), ".git")
+}
+
+// extensionLoaded /extensions 返回各扩展前端脚本的路径（/extensions/<目录名>/...），按目录名匹配，不区分大小写。
+// 没有前端脚本的纯后端扩展不会出现在列表中，会被视为未安装
+func extensionLoaded(paths []string, name string) bool {
+	for _, p := range paths {
+		parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+		if len(parts) >= 2 && parts[0] == "extensions" && strings.EqualFold(parts[1], name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package comfyui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// FakeComfyUIServer 模拟 ComfyUI HTTP API 的测试服务器：/prompt 立即返回 prompt_id，
+// /history 返回一张 SaveImage 输出，/view 返回 8×8 灰色 PNG，/ws 推送 execution_start → progress → executed 事件
+type FakeComfyUIServer struct {
+	*httptest.Server
+
+	// 故障注入，用于混沌测试；需在发出请求前设置
+	SubmitDelay      time.Duration // /prompt 响应前的延迟
+	HistoryDelay     time.Duration // /history 响应前的延迟
+	SubmitErrorRate  float64       // /prompt 随机返回 503 的概率，0~1
+	HistoryErrorRate float64       // /history 随机返回 503 的概率，0~1
+	RejectPrompts    bool          // /prompt 固定返回 400 invalid_prompt，模拟工作流校验失败
+	// LogCapture 非空时按 ComfyUI 控制台格式写入执行日志（每个节点一行 "节点ID: N.NNs"），可交给 ParseExecutionLog 解析
+	LogCapture io.Writer
+
+	mu      sync.Mutex
+	seq     int
+	prompts map[string]map[string]interface{}
+	wsConns map[string]*websocket.Conn
+	// wsQueue 连接建立前提交的任务对应的事件，连接注册后补发
+	wsQueue map[string][]interface{}
+}
+
+func NewFakeComfyUIServer() *FakeComfyUIServer {
+	f := &FakeComfyUIServer{
+		prompts: make(map[string]map[string]interface{}),
+		wsConns: make(map[string]*websocket.Conn),
+		wsQueue: make(map[string][]interface{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/prompt", f.handlePrompt)
+	mux.Handle("/ws", websocket.Handler(f.handleWS))
+	mux.HandleFunc("/history/", f.handleHistory)
+	mux.HandleFunc("/view", f.handleView)
+	mux.HandleFunc("/upload/image", f.handleUpload)
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"queue_running": []interface{}{}, "queue_pending": []interface{}{}})
+	})
+	mux.HandleFunc("/system_stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"system":  map[string]interface{}{"os": "posix", "comfyui_version": "0.3.10"},
+			"devices": []interface{}{map[string]interface{}{"name": "fake", "type": "cuda", "vram_total": 24 << 30, "vram_free": 20 << 30}},
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// Prompts 返回已提交的工作流（prompt_id -> workflow）
+func (f *FakeComfyUIServer) Prompts() map[string]map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]map[string]interface{}, len(f.prompts))
+	for k, v := range f.prompts {
+		out[k] = v
+	}
+	return out
+}
+
+// injectFault 按配置延迟，并以 rate 的概率返回 503；返回 true 表示已写入错误响应
+func injectFault(w http.ResponseWriter, delay time.Duration, rate float64) bool {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if rate > 0 && rand.Float64() < rate {
+		http.Error(w, "injected fault", http.StatusServiceUnavailable)
+		return true
+	}
+	return false
+}
+
+func (f *FakeComfyUIServer) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if injectFault(w, f.SubmitDelay, f.SubmitErrorRate) {
+		return
+	}
+	if f.RejectPrompts {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]interface{}{
+			"error":       map[string]interface{}{"type": "invalid_prompt", "message": "Cannot execute because a node is missing the class_type property."},
+			"node_errors": map[string]interface{}{},
+		})
+		return
+	}
+	var body struct {
+		Prompt   map[string]interface{} `json:"prompt"`
+		ClientID string                 `json:"client_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.seq++
+	id := fmt.Sprintf("fake-%d", f.seq)
+	f.prompts[id] = body.Prompt
+	f.writeLogLocked(body.Prompt)
+	for _, event := range fakeEvents(id) {
+		f.sendWSLocked(body.ClientID, event)
+	}
+	f.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"prompt_id": id, "number": f.seq})
+}
+
+func fakeEvents(id string) []interface{} {
+	node := "8"
+	return []interface{}{
+		map[string]interface{}{"type": "execution_start", "data": map[string]interface{}{"prompt_id": id}},
+		map[string]interface{}{"type": "progress", "data": map[string]interface{}{"prompt_id": id, "value": 1, "max": 1, "node": "15"}},
+		map[string]interface{}{"type": "executed", "data": map[string]interface{}{"prompt_id": id, "node": node, "output": map[string]interface{}{
+			"images": []interface{}{map[string]interface{}{"filename": id + ".png", "subfolder": "", "type": "output"}},
+		}}},
+		map[string]interface{}{"type": "executing", "data": map[string]interface{}{"prompt_id": id, "node": nil}},
+	}
+}
+
+// writeLogLocked 模拟 ComfyUI 的执行日志，节点按 ID 排序、每个耗时 0.01s；调用方需持有 f.mu
+func (f *FakeComfyUIServer) writeLogLocked(prompt map[string]interface{}) {
+	if f.LogCapture == nil {
+		return
+	}
+	fmt.Fprintln(f.LogCapture, "got prompt")
+	for _, id := range sortedKeys(prompt) {
+		fmt.Fprintf(f.LogCapture, "%s: 0.01s\n", id)
+	}
+	fmt.Fprintf(f.LogCapture, "Prompt executed in %.2f seconds\n", 0.01*float64(len(prompt)))
+}
+
+// sendWSLocked 向 clientID 的连接推送事件，尚未连接时先排队；调用方需持有 f.mu
+func (f *FakeComfyUIServer) sendWSLocked(clientID string, event interface{}) {
+	conn, ok := f.wsConns[clientID]
+	if !ok {
+		f.wsQueue[clientID] = append(f.wsQueue[clientID], event)
+		return
+	}
+	_ = websocket.JSON.Send(conn, event)
+}
+
+func (f *FakeComfyUIServer) handleWS(conn *websocket.Conn) {
+	clientID := conn.Request().URL.Query().Get("clientId")
+	f.mu.Lock()
+	f.wsConns[clientID] = conn
+	for _, event := range f.wsQueue[clientID] {
+		_ = websocket.JSON.Send(conn, event)
+	}
+	delete(f.wsQueue, clientID)
+	f.mu.Unlock()
+
+	// 阻塞到客户端断开
+	var discard []byte
+	for websocket.Message.Receive(conn, &discard) == nil {
+	}
+	f.mu.Lock()
+	delete(f.wsConns, clientID)
+	f.mu.Unlock()
+}
+
+func (f *FakeComfyUIServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if injectFault(w, f.HistoryDelay, f.HistoryErrorRate) {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/history/")
+	f.mu.Lock()
+	prompt, ok := f.prompts[id]
+	f.mu.Unlock()
+	if !ok {
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		id: map[string]interface{}{
+			"prompt": []interface{}{0, id, prompt, map[string]interface{}{}, []string{"8"}},
+			"outputs": map[string]interface{}{
+				"8": map[string]interface{}{"images": []interface{}{
+					map[string]interface{}{"filename": id + ".png", "subfolder": "", "type": "output"},
+				}},
+			},
+			"status": map[string]interface{}{"status_str": "success", "completed": true, "messages": []interface{}{}},
+		},
+	})
+}
+
+func (f *FakeComfyUIServer) handleView(w http.ResponseWriter, r *http.Request) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+	img.SetGray(0, 0, color.Gray{Y: 64})
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (f *FakeComfyUIServer) handleUpload(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file.Close()
+	writeJSON(w, map[string]interface{}{"name": header.Filename, "subfolder": "", "type": "input"})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,21 @@
+package comfyui
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// fallbackImage 等待超时时上传 FallbackImagePath 并返回其 /view 地址（type=input）
+func (c *Client) fallbackImage(ctx context.Context) (string, error) {
+	name, err := c.uploadFile(ctx, c.FallbackImagePath)
+	if err != nil {
+		return "", err
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return "", err
+	}
+	subfolder, filename := path.Split(name)
+	return ImageURL(baseURL, filename, strings.TrimSuffix(subfolder, "/"), ImageTypeInput), nil
+}
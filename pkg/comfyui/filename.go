@@ -0,0 +1,53 @@
+package comfyui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FilenameStrategy 输出图片的命名方式，对应 SaveImage 的 filename_prefix
+type FilenameStrategy string
+
+const (
+	FilenameUUID      FilenameStrategy = "uuid"      // 每次生成一个随机 UUID
+	FilenameTimestamp FilenameStrategy = "timestamp" // 提交时间（UTC），如 20240102T150405.123456789Z，不含 Windows 与部分对象存储不接受的 : +
+	// FilenameContentHash ComfyUI 无法预知内容，filename_prefix 保持默认；
+	// Generate 下载图片后在 GenerateResult.Filename 中给出 "<sha256>.png"，由调用方保存时使用
+	FilenameContentHash FilenameStrategy = "content_hash"
+)
+
+// timestampFilenameLayout FilenameTimestamp 的时间格式，定长且按字典序即时间序
+const timestampFilenameLayout = "20060102T150405.000000000Z"
+
+// filenamePrefix SaveImage 的 filename_prefix；OutputDatePrefix 为 true 时加上 "YYYY-MM-DD/"，
+// ComfyUI 会把 filename_prefix 中的目录部分作为 output 下的子目录
+func (c *Client) filenamePrefix(p *Params) string {
+	name := c.filenameBase(p)
+	if c.OutputDatePrefix {
+		return time.Now().Format("2006-01-02") + "/" + name
+	}
+	return name
+}
+
+// filenameBase FilenameStrategy 优先，其次 Params.SeedInFilename
+func (c *Client) filenameBase(p *Params) string {
+	switch c.FilenameStrategy {
+	case FilenameUUID:
+		return uuid.NewString()
+	case FilenameTimestamp:
+		return time.Now().UTC().Format(timestampFilenameLayout)
+	}
+	if p.SeedInFilename {
+		return fmt.Sprintf("drama_%d", p.Seed)
+	}
+	return "comfy_ui_generated"
+}
+
+func contentHashFilename(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ".png"
+}
@@ -0,0 +1,71 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ListOutputSubfolders 列出 output 目录下有图片的子目录（不含根目录），供素材管理界面浏览；
+// 优先使用 GET /view/folders（新版或装有相应插件的 ComfyUI），不可用时从 /history 中汇总
+func (c *Client) ListOutputSubfolders(ctx context.Context) ([]string, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	found, err := c.getJSON(ctx, baseURL+"/view/folders", &folders)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui list output subfolders: %w", err)
+	}
+	if found {
+		sort.Strings(folders)
+		return folders, nil
+	}
+
+	var history map[string]HistoryEntry
+	if _, err := c.getJSON(ctx, baseURL+"/history", &history); err != nil {
+		return nil, fmt.Errorf("comfyui list output subfolders: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, entry := range history {
+		for _, out := range entry.Outputs {
+			for _, img := range out.Images {
+				if img.Type == string(ImageTypeOutput) && img.Subfolder != "" {
+					seen[img.Subfolder] = true
+				}
+			}
+		}
+	}
+	folders = make([]string, 0, len(seen))
+	for f := range seen {
+		folders = append(folders, f)
+	}
+	sort.Strings(folders)
+	return folders, nil
+}
+
+// getJSON GET 并解码 JSON 响应；404 时返回 found=false 而不是错误
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) (found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("GET %s: %s", req.URL.Path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", req.URL.Path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultGalleryPerPage = 20
+	maxGalleryPerPage     = 100
+)
+
+// GalleryItem /gallery 中的一张图片
+type GalleryItem struct {
+	PromptID  string            `json:"promptID"`
+	ImageURL  string            `json:"imageURL"`
+	Timestamp time.Time         `json:"timestamp"`
+	Params    map[string]string `json:"params,omitempty"` // 提交时的 Params.Metadata
+}
+
+// GalleryPage /gallery 的分页响应
+type GalleryPage struct {
+	Page    int           `json:"page"`
+	PerPage int           `json:"perPage"`
+	Total   int           `json:"total"`
+	Items   []GalleryItem `json:"items"`
+}
+
+// serveGallery GET /gallery?page=1&perPage=20，按时间倒序分页列出 history 中每个任务的第一张输出图
+func serveGallery(c *Client, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	page := queryInt(r, "page", 1)
+	perPage := queryInt(r, "perPage", defaultGalleryPerPage)
+	if perPage > maxGalleryPerPage {
+		perPage = maxGalleryPerPage
+	}
+
+	history, err := c.GetAllHistory(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	baseURL, _ := c.baseURL()
+	items := make([]GalleryItem, 0, len(history))
+	for id, entry := range history {
+		imgs := entry.NodeImages("")
+		if len(imgs) == 0 {
+			continue
+		}
+		imageURL, err := historyImageURL(baseURL, imgs[0])
+		if err != nil {
+			continue
+		}
+		items = append(items, GalleryItem{PromptID: id, ImageURL: imageURL, Timestamp: entry.Status.StartedAt(), Params: entry.Metadata})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Timestamp.Equal(items[j].Timestamp) {
+			return items[i].Timestamp.After(items[j].Timestamp)
+		}
+		return items[i].PromptID < items[j].PromptID
+	})
+
+	resp := GalleryPage{Page: page, PerPage: perPage, Total: len(items), Items: []GalleryItem{}}
+	if start := (page - 1) * perPage; start < len(items) {
+		end := start + perPage
+		if end > len(items) {
+			end = len(items)
+		}
+		resp.Items = items[start:end]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// queryInt 读取正整数查询参数，缺省或非法时返回 def
+func queryInt(r *http.Request, name string, def int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,160 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Generator 文生图接口，*Client 与各类多实例分组均实现该接口
+type Generator interface {
+	Generate(ctx context.Context, p *Params) (*GenerateResult, error)
+}
+
+// queueProbeTimeout 选择实例前查询队列的超时时间
+const queueProbeTimeout = 2 * time.Second
+
+// LeastLoadedGroup 每次生成前并发查询各实例的队列，提交到 Running+Pending 最少的实例
+type LeastLoadedGroup struct {
+	backends []*Client
+	next     uint32
+}
+
+func NewLeastLoadedGroup(backends []*Client) *LeastLoadedGroup {
+	return &LeastLoadedGroup{backends: backends}
+}
+
+func (g *LeastLoadedGroup) Generate(ctx context.Context, p *Params) (*GenerateResult, error) {
+	c, err := g.pick(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Generate(ctx, p)
+}
+
+// pick 选出负载最低的实例；所有实例的队列都查询失败时退化为轮询
+func (g *LeastLoadedGroup) pick(ctx context.Context) (*Client, error) {
+	if len(g.backends) == 0 {
+		return nil, fmt.Errorf("comfyui group has no backends")
+	}
+	ctx, cancel := context.WithTimeout(ctx, queueProbeTimeout)
+	defer cancel()
+
+	depths := make([]int, len(g.backends))
+	var wg sync.WaitGroup
+	for i, c := range g.backends {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			depths[i] = -1
+			if q, err := c.QueueStatus(ctx); err == nil {
+				depths[i] = q.RunningCount + q.PendingCount
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	best := -1
+	for i, d := range depths {
+		if d >= 0 && (best < 0 || d < depths[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		n := atomic.AddUint32(&g.next, 1)
+		best = int(n-1) % len(g.backends)
+	}
+	return g.backends[best], nil
+}
+
+// defaultPingRefreshInterval LatencyRouter 重新测速的默认间隔
+const defaultPingRefreshInterval = 5 * time.Minute
+
+// LatencyRouter 多地域部署时按 /system_stats 往返时延排序各实例，提交到时延最低的实例；
+// 排名缓存 PingRefreshInterval，过期后下一次生成前重新测速
+type LatencyRouter struct {
+	// PingRefreshInterval 排名的有效期，默认 5 分钟
+	PingRefreshInterval time.Duration
+
+	backends []*Client
+	mu       sync.Mutex
+	ranking  []*Client
+	rankedAt time.Time
+}
+
+func NewLatencyRouter(backends []*Client) *LatencyRouter {
+	return &LatencyRouter{backends: backends}
+}
+
+func (r *LatencyRouter) Generate(ctx context.Context, p *Params) (*GenerateResult, error) {
+	return r.GenerateWithRouter(ctx, p)
+}
+
+// GenerateWithRouter 提交到当前时延最低的实例
+func (r *LatencyRouter) GenerateWithRouter(ctx context.Context, p *Params) (*GenerateResult, error) {
+	ranking, err := r.Ranking(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ranking[0].Generate(ctx, p)
+}
+
+// Ranking 返回按时延从低到高排列的实例，测速失败的实例排在最后
+func (r *LatencyRouter) Ranking(ctx context.Context) ([]*Client, error) {
+	if len(r.backends) == 0 {
+		return nil, fmt.Errorf("comfyui router has no backends")
+	}
+	interval := r.PingRefreshInterval
+	if interval <= 0 {
+		interval = defaultPingRefreshInterval
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ranking == nil || time.Since(r.rankedAt) >= interval {
+		r.ranking = r.rank(ctx)
+		r.rankedAt = time.Now()
+	}
+	return r.ranking, nil
+}
+
+// rank 并发请求各实例的 /system_stats 并按往返时间排序
+func (r *LatencyRouter) rank(ctx context.Context) []*Client {
+	ctx, cancel := context.WithTimeout(ctx, queueProbeTimeout)
+	defer cancel()
+
+	rtts := make([]time.Duration, len(r.backends))
+	var wg sync.WaitGroup
+	for i, c := range r.backends {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			start := time.Now()
+			if _, err := c.GetSystemStats(ctx); err != nil {
+				rtts[i] = -1
+				return
+			}
+			rtts[i] = time.Since(start)
+		}(i, c)
+	}
+	wg.Wait()
+
+	order := make([]int, len(r.backends))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := rtts[order[a]], rtts[order[b]]
+		if (ra < 0) != (rb < 0) {
+			return rb < 0
+		}
+		return ra < rb
+	})
+	ranking := make([]*Client, len(order))
+	for i, idx := range order {
+		ranking[i] = r.backends[idx]
+	}
+	return ranking
+}
@@ -0,0 +1,67 @@
+package comfyui
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHealthCheckTTL = 30 * time.Second
+
+// healthState 最近一次 HealthCheck 成功的时间（UnixNano，0 表示从未成功）
+type healthState struct {
+	lastOK atomic.Int64
+	// queueDepth 上次通知 OnQueueDepthChanged 的队列深度 + 1，0 表示尚未通知过
+	queueDepth atomic.Int64
+}
+
+// HealthCheck 请求 /system_stats 确认 ComfyUI 可用，成功时记录时间供 /livez 使用
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if _, err := c.GetSystemStats(ctx); err != nil {
+		return err
+	}
+	c.health.lastOK.Store(time.Now().UnixNano())
+	return nil
+}
+
+// healthy BaseURL 已配置且最近一次 HealthCheck 成功在 HealthCheckTTL 之内
+func (c *Client) healthy() bool {
+	if _, err := c.baseURL(); err != nil {
+		return false
+	}
+	last := c.health.lastOK.Load()
+	if last == 0 {
+		return false
+	}
+	ttl := c.HealthCheckTTL
+	if ttl <= 0 {
+		ttl = defaultHealthCheckTTL
+	}
+	return time.Since(time.Unix(0, last)) <= ttl
+}
+
+// NewHTTPHandler 暴露给 Kubernetes 等探针使用的 HTTP 接口：
+//
+//	GET /livez   200 表示 ComfyUI 可达，503 表示不可达或 HealthCheck 已过期
+//	GET /gallery history 中的输出图片，按时间倒序分页，见 GalleryPage
+//
+// 处理器只读取 HealthCheck 的结果，调用方需定期执行 HealthCheck
+func NewHTTPHandler(c *Client) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !c.healthy() {
+			http.Error(w, "comfyui unreachable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/gallery", func(w http.ResponseWriter, r *http.Request) {
+		serveGallery(c, w, r)
+	})
+	return mux
+}
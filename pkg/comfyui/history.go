@@ -0,0 +1,415 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// ErrNodeExecution 工作流中有节点执行失败，具体信息见 *NodeExecutionError
+var ErrNodeExecution = errors.New("comfyui node execution failed")
+
+// ErrExecutionInterrupted 任务被 /interrupt 中断（见 Cancel），errors.Is(err, ErrNodeExecution) 同样为 true
+var ErrExecutionInterrupted = errors.New("comfyui execution interrupted")
+
+// ErrNodeErrors ErrNodeExecution 的别名
+var ErrNodeErrors = ErrNodeExecution
+
+// NodeExecutionError 携带失败节点及错误信息，errors.Is(err, ErrNodeExecution) 为 true
+type NodeExecutionError struct {
+	NodeErrors map[string]string
+}
+
+func (e *NodeExecutionError) Error() string {
+	ids := make([]string, 0, len(e.NodeErrors))
+	for id := range e.NodeErrors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msg := ErrNodeExecution.Error()
+	for _, id := range ids {
+		msg += fmt.Sprintf("; node %s: %s", id, e.NodeErrors[id])
+	}
+	return msg
+}
+
+func (e *NodeExecutionError) Unwrap() error { return ErrNodeExecution }
+
+// ExecutionError ComfyUI 执行 prompt 时抛出的异常（history status.messages 或 /ws 中的 execution_error），
+// 如节点配置错误、模型文件缺失、CUDA OOM；errors.Is(err, ErrNodeExecution) 为 true
+type ExecutionError struct {
+	PromptID         string
+	NodeID           string
+	NodeType         string
+	ExceptionType    string
+	ExceptionMessage string
+	// Executed 出错前已成功执行的节点 ID
+	Executed []string
+	// Traceback 服务器端的 Python 调用栈（逐行），排查自定义节点问题时使用，不计入 Error()
+	Traceback []string
+	// Interrupted 任务被中断而不是出错，此时 errors.Is(err, ErrExecutionInterrupted) 为 true
+	Interrupted bool
+}
+
+func (e *ExecutionError) Error() string {
+	if e.Interrupted {
+		return fmt.Sprintf("%s: prompt %s at node %s (%s)", ErrExecutionInterrupted, e.PromptID, e.NodeID, e.NodeType)
+	}
+	if e.NodeID == "" {
+		return fmt.Sprintf("%s: %s", ErrNodeExecution, e.ExceptionMessage)
+	}
+	return fmt.Sprintf("%s: node %s (%s): %s: %s", ErrNodeExecution, e.NodeID, e.NodeType, e.ExceptionType, e.ExceptionMessage)
+}
+
+func (e *ExecutionError) Unwrap() error { return ErrNodeExecution }
+
+func (e *ExecutionError) Is(target error) bool {
+	return e.Interrupted && target == ErrExecutionInterrupted
+}
+
+// HistoryStatus history 记录中的 status 字段
+type HistoryStatus struct {
+	StatusStr string           `json:"status_str"`
+	Completed bool             `json:"completed"`
+	Messages  []HistoryMessage `json:"messages"`
+}
+
+// HistoryMessage status.messages 中的一条消息，ComfyUI 以 [type, data] 数组形式返回
+type HistoryMessage struct {
+	Type      string
+	ExtraData HistoryMessageData
+}
+
+// HistoryMessageData 消息内容，只解析 execution_error / execution_interrupted 与时间戳用到的字段
+type HistoryMessageData struct {
+	PromptID         string   `json:"prompt_id"`
+	Timestamp        int64    `json:"timestamp"` // 毫秒
+	NodeID           string   `json:"node_id"`
+	NodeType         string   `json:"node_type"`
+	ExceptionType    string   `json:"exception_type"`
+	ExceptionMessage string   `json:"exception_message"`
+	Executed         []string `json:"executed"`
+	Traceback        []string `json:"traceback"`
+}
+
+func (m *HistoryMessage) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("comfyui history message: want [type, data], got %d elements", len(pair))
+	}
+	if err := json.Unmarshal(pair[0], &m.Type); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &m.ExtraData)
+}
+
+// ExecutionError 返回第一条 execution_error 或 execution_interrupted 消息；status_str 为 error 但没有对应消息时
+// 同样返回（不含节点信息），任务成功或尚未结束时为 nil
+func (s HistoryStatus) ExecutionError() *ExecutionError {
+	for _, m := range s.Messages {
+		d := m.ExtraData
+		switch m.Type {
+		case "execution_error":
+			return &ExecutionError{
+				PromptID: d.PromptID, NodeID: d.NodeID, NodeType: d.NodeType,
+				ExceptionType: d.ExceptionType, ExceptionMessage: d.ExceptionMessage, Executed: d.Executed,
+				Traceback: d.Traceback,
+			}
+		case "execution_interrupted":
+			return &ExecutionError{PromptID: d.PromptID, NodeID: d.NodeID, NodeType: d.NodeType, Executed: d.Executed, Interrupted: true}
+		}
+	}
+	if s.StatusStr == "error" {
+		return &ExecutionError{ExceptionMessage: "prompt marked as failed (status_str=error)"}
+	}
+	return nil
+}
+
+// StartedAt 任务开始执行的时间（取第一条带时间戳的消息），没有则为零值
+func (s HistoryStatus) StartedAt() time.Time {
+	for _, m := range s.Messages {
+		if m.ExtraData.Timestamp > 0 {
+			return time.UnixMilli(m.ExtraData.Timestamp)
+		}
+	}
+	return time.Time{}
+}
+
+// ErrResultMismatch 返回的图片 URL 与 history 中记录的输出不一致
+var ErrResultMismatch = errors.New("comfyui result url does not match history")
+
+// ErrUnrecognizedHistoryFormat /history 响应既不是 v1（对象）也不是 v2（数组）格式
+var ErrUnrecognizedHistoryFormat = errors.New("comfyui unrecognized history format")
+
+// HistoryImage /history 输出中的单张图片
+type HistoryImage = api.HistoryImage
+
+// HistoryOutput 单个输出节点的结果；视频节点的结果在 Gifs（VideoHelperSuite 的 VHS_VideoCombine）或 Videos 中，
+// SaveAnimatedWEBP 等内置动图节点仍写入 Images 并把 Animated 置为 true
+type HistoryOutput struct {
+	Images   []HistoryImage `json:"images"`
+	Gifs     []HistoryImage `json:"gifs,omitempty"`
+	Videos   []HistoryImage `json:"videos,omitempty"`
+	Animated []bool         `json:"animated,omitempty"`
+}
+
+// videos 节点输出的视频 / 动图文件
+func (o HistoryOutput) videos() []HistoryImage {
+	files := append(append([]HistoryImage(nil), o.Videos...), o.Gifs...)
+	if len(o.Animated) > 0 && o.Animated[0] {
+		files = append(files, o.Images...)
+	}
+	return files
+}
+
+// HistoryEntry /history/{prompt_id} 中某个 prompt 的记录
+type HistoryEntry struct {
+	// PromptID 记录对应的 prompt_id
+	PromptID string                   `json:"-"`
+	Outputs  map[string]HistoryOutput `json:"outputs"`
+	Status   HistoryStatus            `json:"status"`
+	// NodeErrors 执行失败的节点（节点 ID -> 错误信息），见 parseNodeErrors
+	NodeErrors map[string]string `json:"-"`
+	// Metadata 提交时 Params.Metadata 的内容，见 addMetadataNode
+	Metadata map[string]string `json:"-"`
+	// Raw ComfyUI 返回的原始记录
+	Raw json.RawMessage `json:"-"`
+}
+
+// FirstImage 按节点 ID 顺序返回第一张输出图片，保证多次调用结果一致
+func (e *HistoryEntry) FirstImage() (HistoryImage, bool) {
+	if imgs := e.NodeImages(""); len(imgs) > 0 {
+		return imgs[0], true
+	}
+	return HistoryImage{}, false
+}
+
+// outputImages 按 outputNodeID 选出结果图片：allOutputNodes 时为 AllImages，否则为 NodeImages
+func (e *HistoryEntry) outputImages(outputNodeID string) []HistoryImage {
+	if outputNodeID == allOutputNodes {
+		return e.AllImages()
+	}
+	return e.NodeImages(outputNodeID)
+}
+
+// NodeImages 返回 nodeID 节点的全部输出图片；nodeID 为空时按节点 ID 顺序取第一个有图片的节点
+func (e *HistoryEntry) NodeImages(nodeID string) []HistoryImage {
+	if nodeID != "" {
+		return e.Outputs[nodeID].Images
+	}
+	for _, id := range sortedOutputIDs(e.Outputs) {
+		if imgs := e.Outputs[id].Images; len(imgs) > 0 {
+			return imgs
+		}
+	}
+	return nil
+}
+
+// AllImages 按节点 ID 顺序返回全部输出节点的图片；有 output 类型的图片时忽略 PreviewImage 等节点的 temp 图片
+func (e *HistoryEntry) AllImages() []HistoryImage {
+	var output, others []HistoryImage
+	for _, id := range sortedOutputIDs(e.Outputs) {
+		for _, img := range e.Outputs[id].Images {
+			if img.Type == string(ImageTypeOutput) {
+				output = append(output, img)
+			} else {
+				others = append(others, img)
+			}
+		}
+	}
+	if len(output) > 0 {
+		return output
+	}
+	return others
+}
+
+// NodeVideos 返回 nodeID 节点输出的视频文件；nodeID 为空时按节点 ID 顺序取第一个有视频的节点
+func (e *HistoryEntry) NodeVideos(nodeID string) []HistoryImage {
+	if nodeID != "" {
+		return e.Outputs[nodeID].videos()
+	}
+	for _, id := range sortedOutputIDs(e.Outputs) {
+		if files := e.Outputs[id].videos(); len(files) > 0 {
+			return files
+		}
+	}
+	return nil
+}
+
+func sortedOutputIDs(outputs map[string]HistoryOutput) []string {
+	ids := make([]string, 0, len(outputs))
+	for id := range outputs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetHistory 查询 /history/{prompt_id}；任务尚未出现在 history 中时返回 nil, nil
+func (c *Client) GetHistory(ctx context.Context, promptID string) (*HistoryEntry, error) {
+	history, err := c.fetchHistory(ctx, func(cl *api.Client) (*http.Response, error) {
+		return cl.GetPromptHistory(ctx, promptID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history[promptID], nil
+}
+
+// GetAllHistory 查询 /history，返回服务器保留的全部记录（prompt_id -> 记录）
+func (c *Client) GetAllHistory(ctx context.Context) (map[string]*HistoryEntry, error) {
+	return c.fetchHistory(ctx, func(cl *api.Client) (*http.Response, error) {
+		return cl.GetHistory(ctx, nil)
+	})
+}
+
+func (c *Client) fetchHistory(ctx context.Context, get func(cl *api.Client) (*http.Response, error)) (map[string]*HistoryEntry, error) {
+	cl, err := c.apiClient(true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(cl)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui history: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("comfyui history %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui history: %w", err)
+	}
+	return parseHistoryResponse(body)
+}
+
+// parseHistoryResponse 解析 /history 响应：先按官方的 v1 格式（prompt_id -> 记录）解析，
+// 失败时按部分分支的 v2 格式（记录数组，每条带 prompt_id 字段）解析，都不符合时返回 ErrUnrecognizedHistoryFormat
+func parseHistoryResponse(body []byte) (map[string]*HistoryEntry, error) {
+	var v1 map[string]json.RawMessage
+	if err := json.Unmarshal(body, &v1); err == nil {
+		entries := make(map[string]*HistoryEntry, len(v1))
+		for id, raw := range v1 {
+			entry, err := decodeHistoryEntry(raw)
+			if err != nil {
+				return nil, err
+			}
+			entry.PromptID = id
+			entries[id] = entry
+		}
+		return entries, nil
+	}
+
+	var v2 []json.RawMessage
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, ErrUnrecognizedHistoryFormat
+	}
+	entries := make(map[string]*HistoryEntry, len(v2))
+	for _, raw := range v2 {
+		var item struct {
+			PromptID string `json:"prompt_id"`
+		}
+		if err := json.Unmarshal(raw, &item); err != nil || item.PromptID == "" {
+			return nil, fmt.Errorf("%w: v2 entry without prompt_id", ErrUnrecognizedHistoryFormat)
+		}
+		entry, err := decodeHistoryEntry(raw)
+		if err != nil {
+			return nil, err
+		}
+		entry.PromptID = item.PromptID
+		entries[item.PromptID] = entry
+	}
+	return entries, nil
+}
+
+func decodeHistoryEntry(raw json.RawMessage) (*HistoryEntry, error) {
+	var entry HistoryEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, fmt.Errorf("comfyui decode history: %w", err)
+	}
+	entry.Raw = raw
+	var generic map[string]interface{}
+	if err := json.Unmarshal(raw, &generic); err == nil {
+		entry.NodeErrors = parseNodeErrors(generic)
+		entry.Metadata = parseHistoryMetadata(generic)
+	}
+	return &entry, nil
+}
+
+// parseNodeErrors 从 history 记录中提取各节点的错误信息（节点 ID -> 错误描述），来源包括：
+// outputs.<node>.error 字段，以及 status.messages 中的 execution_error 消息
+func parseNodeErrors(history map[string]interface{}) map[string]string {
+	errs := map[string]string{}
+	if outputs, ok := history["outputs"].(map[string]interface{}); ok {
+		for nodeID, out := range outputs {
+			node, _ := out.(map[string]interface{})
+			switch e := node["error"].(type) {
+			case string:
+				errs[nodeID] = e
+			case map[string]interface{}:
+				if msg, _ := e["message"].(string); msg != "" {
+					errs[nodeID] = msg
+				} else if b, err := json.Marshal(e); err == nil {
+					errs[nodeID] = string(b)
+				}
+			}
+		}
+	}
+	if status, ok := history["status"].(map[string]interface{}); ok {
+		messages, _ := status["messages"].([]interface{})
+		for _, m := range messages {
+			pair, _ := m.([]interface{})
+			if len(pair) != 2 || pair[0] != "execution_error" {
+				continue
+			}
+			data, _ := pair[1].(map[string]interface{})
+			nodeID, _ := data["node_id"].(string)
+			msg, _ := data["exception_message"].(string)
+			if nodeID != "" {
+				errs[nodeID] = msg
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateResult 重新拉取 history，按生成 URL 时相同的规则选出 outputNodeID 的输出（allOutputNodes 为全部节点），
+// 解析各 imageURL 的查询参数并与记录的输出逐项比对
+func (c *Client) validateResult(ctx context.Context, promptID, outputNodeID string, imageURLs []string) error {
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrResultMismatch
+	}
+	imgs := entry.outputImages(outputNodeID)
+	if len(imgs) != len(imageURLs) {
+		return fmt.Errorf("%w: got %d images, history has %d", ErrResultMismatch, len(imageURLs), len(imgs))
+	}
+	for i, imageURL := range imageURLs {
+		u, err := url.Parse(imageURL)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrResultMismatch, err)
+		}
+		q, img := u.Query(), imgs[i]
+		if q.Get("filename") != img.Filename || q.Get("subfolder") != img.Subfolder || q.Get("type") != img.Type {
+			return fmt.Errorf("%w: got %s", ErrResultMismatch, imageURL)
+		}
+	}
+	return nil
+}
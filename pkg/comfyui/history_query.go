@@ -0,0 +1,70 @@
+package comfyui
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// HistoryQuery QueryHistory 的过滤条件，零值字段不过滤
+type HistoryQuery struct {
+	From, To time.Time // 按 HistoryStatus.StartedAt 过滤，闭区间；没有时间戳的记录在设置了任一端时被排除
+	Status   string    // status_str，如 success / error
+	// Tags 需与 HistoryEntry.Metadata（Params.Metadata）中的同名键全部相等
+	Tags  map[string]string
+	Limit int // 最多返回的条数，<= 0 不限
+}
+
+// QueryHistory 按时间倒序返回符合条件的 history 记录。ComfyUI 的 /history 只支持 max_items，
+// 仅设置了 Limit 时交给服务器截取，其它条件在客户端过滤
+func (c *Client) QueryHistory(ctx context.Context, q HistoryQuery) ([]*HistoryEntry, error) {
+	params := &api.GetHistoryParams{}
+	serverLimit := q.Limit > 0 && q.From.IsZero() && q.To.IsZero() && q.Status == "" && len(q.Tags) == 0
+	if serverLimit {
+		params.MaxItems = &q.Limit
+	}
+	history, err := c.fetchHistory(ctx, func(cl *api.Client) (*http.Response, error) {
+		return cl.GetHistory(ctx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*HistoryEntry, 0, len(history))
+	for _, entry := range history {
+		if q.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		ti, tj := entries[i].Status.StartedAt(), entries[j].Status.StartedAt()
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return entries[i].PromptID < entries[j].PromptID
+	})
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (q HistoryQuery) matches(entry *HistoryEntry) bool {
+	if q.Status != "" && entry.Status.StatusStr != q.Status {
+		return false
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		started := entry.Status.StartedAt()
+		if started.IsZero() || (!q.From.IsZero() && started.Before(q.From)) || (!q.To.IsZero() && started.After(q.To)) {
+			return false
+		}
+	}
+	for k, v := range q.Tags {
+		if got, ok := entry.Metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
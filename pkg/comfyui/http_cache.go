@@ -0,0 +1,96 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheablePaths 可缓存的只读接口前缀
+var cacheablePaths = []string{"/system_stats", "/object_info", "/queue", "/models"}
+
+type cachedResponse struct {
+	status    int
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+type bypassCacheKey struct{}
+
+// withoutCache 该 ctx 发出的请求不读也不写 cachingTransport，用于必须拿到最新状态的查询（如取消前查队列位置）
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// cachingTransport 按完整 URL 缓存只读接口的 200 响应；写入时顺带清理已过期的条目
+type cachingTransport struct {
+	ttl  time.Duration
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if bypass, _ := req.Context().Value(bypassCacheKey{}).(bool); bypass || req.Method != http.MethodGet || !isCacheablePath(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+	t.mu.Lock()
+	entry, ok := t.entries[key]
+	t.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.response(req), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entry = cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body, expiresAt: now.Add(t.ttl)}
+	t.mu.Lock()
+	for k, e := range t.entries {
+		if !now.Before(e.expiresAt) {
+			delete(t.entries, k)
+		}
+	}
+	t.entries[key] = entry
+	t.mu.Unlock()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (e cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func isCacheablePath(p string) bool {
+	for _, prefix := range cacheablePaths {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+)
+
+// ControlType GenerateFromImage 使用参考图的方式
+type ControlType string
+
+const (
+	// ControlImg2Img 以参考图为起点重绘（LoadImage → VAEEncode），输出尺寸随参考图
+	ControlImg2Img ControlType = "img2img"
+	// ControlIPAdapter 以参考图约束角色 / 画风（Flux IP-Adapter），构图仍由提示词决定
+	ControlIPAdapter ControlType = "ipadapter"
+	// ControlNet 以参考图约束构图（ControlNetApplyAdvanced），参考图需是对应模型的控制图（边缘、深度、姿态等）
+	ControlNet ControlType = "controlnet"
+)
+
+// DefaultControlNetModel ImageParams.ControlNetModel 为空时使用的 ControlNet 模型
+var DefaultControlNetModel = "flux\\flux-canny-controlnet-v3.safetensors"
+
+// ControlNet 节点：ControlNetLoader(110) → ControlNetApplyAdvanced(111)，输出替换节点 15 的 positive / negative
+const (
+	controlNetLoaderNodeID = "110"
+	controlNetApplyNodeID  = "111"
+)
+
+// ImageParams 带参考图的生图参数，用于分镜之间保持角色一致
+type ImageParams struct {
+	Params
+	// ImageName 参考图在 ComfyUI input 目录中的文件名，即 UploadImage 的返回值
+	ImageName   string      `json:"image_name" yaml:"image_name"`
+	ControlType ControlType `json:"control_type" yaml:"control_type"` // 为空时为 img2img
+	// Denoise img2img 的重绘幅度（0~1），默认 0.6；其它方式下为参考图强度（IP-Adapter 的 ip_scale / ControlNet 的 strength），默认 0.6
+	Denoise float64 `json:"denoise,omitempty" yaml:"denoise,omitempty"`
+	// ControlNetModel ControlType 为 controlnet 时使用的模型，为空时为 DefaultControlNetModel
+	ControlNetModel string `json:"controlnet_model,omitempty" yaml:"controlnet_model,omitempty"`
+}
+
+// GenerateFromImage 用已上传的参考图按 ControlType 构建 LoadImage 工作流并生成，返回图片 URL；
+// 本地图片先用 UploadImage 上传
+func (c *Client) GenerateFromImage(ctx context.Context, p *ImageParams) (string, error) {
+	if p.ImageName == "" {
+		return "", fmt.Errorf("comfyui reference image name is required")
+	}
+	var patch func(workflow map[string]interface{}) error
+	switch p.ControlType {
+	case "", ControlImg2Img:
+		patch = func(workflow map[string]interface{}) error {
+			insertImg2Img(workflow, p.ImageName, p.Denoise)
+			return nil
+		}
+	case ControlIPAdapter:
+		patch = func(workflow map[string]interface{}) error {
+			return insertIPAdapter(workflow, p.ImageName, controlStrength(p.Denoise))
+		}
+	case ControlNet:
+		patch = func(workflow map[string]interface{}) error {
+			return insertControlNet(workflow, p.ImageName, p.ControlNetModel, controlStrength(p.Denoise))
+		}
+	default:
+		return "", fmt.Errorf("comfyui unknown control type %q", p.ControlType)
+	}
+	imageURL, err := c.generate(ctx, &p.Params, patch, nil)
+	return imageURL, c.localizeError(err)
+}
+
+func controlStrength(v float64) float64 {
+	if v <= 0 || v > 1 {
+		return defaultIPAdapterScale
+	}
+	return v
+}
+
+// insertControlNet 插入参考图 LoadImage(30) → ControlNetApplyAdvanced(111)，改写节点 15 的正负条件
+func insertControlNet(workflow map[string]interface{}, image, model string, strength float64) error {
+	if model == "" {
+		model = DefaultControlNetModel
+	}
+	positive, negative, err := samplerConditioning(workflow)
+	if err != nil {
+		return err
+	}
+	workflow["30"] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"image": image},
+		"class_type": "LoadImage",
+	}
+	workflow[controlNetLoaderNodeID] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"control_net_name": model},
+		"class_type": "ControlNetLoader",
+	}
+	workflow[controlNetApplyNodeID] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"positive":      positive,
+			"negative":      negative,
+			"control_net":   []interface{}{controlNetLoaderNodeID, 0},
+			"image":         []interface{}{"30", 0},
+			"vae":           []interface{}{"19", 0},
+			"strength":      strength,
+			"start_percent": 0.0,
+			"end_percent":   1.0,
+		},
+		"class_type": "ControlNetApplyAdvanced",
+	}
+	return setSamplerConditioning(workflow, []interface{}{controlNetApplyNodeID, 0}, []interface{}{controlNetApplyNodeID, 1})
+}
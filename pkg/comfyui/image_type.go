@@ -0,0 +1,34 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrUnknownImageType history 中出现了无法识别的图片类型
+var ErrUnknownImageType = errors.New("comfyui unknown image type")
+
+// ImageType ComfyUI 图片所在目录类型，对应 /view 的 type 参数
+type ImageType string
+
+const (
+	ImageTypeOutput ImageType = "output" // SaveImage 输出
+	ImageTypeTemp   ImageType = "temp"   // PreviewImage 等临时输出
+	ImageTypeInput  ImageType = "input"  // 上传或 LoadImage 使用的输入图
+)
+
+// ParseImageType 校验 history 中的 type 字段
+func ParseImageType(s string) (ImageType, error) {
+	switch t := ImageType(s); t {
+	case ImageTypeOutput, ImageTypeTemp, ImageTypeInput:
+		return t, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownImageType, s)
+}
+
+// ImageURL 拼接 /view 图片地址；参数按查询串转义，文件名中的 + & 空格与多级子目录（如 "drama/season1"）中的 / 都能原样传给 ComfyUI
+func ImageURL(baseURL, filename, subfolder string, imageType ImageType) string {
+	q := url.Values{"filename": {filename}, "subfolder": {subfolder}, "type": {string(imageType)}}
+	return baseURL + "/view?" + q.Encode()
+}
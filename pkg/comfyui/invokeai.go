@@ -0,0 +1,150 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnsupportedNode 节点类型在 InvokeAI 中没有对应的等价节点
+var ErrUnsupportedNode = errors.New("comfyui node has no invokeai equivalent")
+
+// invokeAINodeMapping ComfyUI 节点到 InvokeAI 节点的转换规则
+type invokeAINodeMapping struct {
+	Type    string            // InvokeAI 节点类型
+	Inputs  map[string]string // ComfyUI 输入名 -> InvokeAI 字段名，未列出的输入丢弃
+	Outputs []string          // 按 ComfyUI 输出序号排列的 InvokeAI 输出字段名
+}
+
+// invokeAINodes 常见节点的转换表
+var invokeAINodes = map[string]invokeAINodeMapping{
+	"CheckpointLoaderSimple": {
+		Type:    "main_model_loader",
+		Inputs:  map[string]string{"ckpt_name": "model"},
+		Outputs: []string{"unet", "clip", "vae"},
+	},
+	"UNETLoader": {
+		Type:    "flux_model_loader",
+		Inputs:  map[string]string{"unet_name": "model"},
+		Outputs: []string{"transformer"},
+	},
+	"DualCLIPLoader": {
+		Type:    "flux_model_loader",
+		Inputs:  map[string]string{"clip_name1": "t5_encoder_model", "clip_name2": "clip_embed_model"},
+		Outputs: []string{"clip"},
+	},
+	"VAELoader": {
+		Type:    "vae_loader",
+		Inputs:  map[string]string{"vae_name": "vae_model"},
+		Outputs: []string{"vae"},
+	},
+	"CLIPTextEncode": {
+		Type:    "compel",
+		Inputs:  map[string]string{"text": "prompt", "clip": "clip"},
+		Outputs: []string{"conditioning"},
+	},
+	"EmptyLatentImage": {
+		Type:    "noise",
+		Inputs:  map[string]string{"width": "width", "height": "height"},
+		Outputs: []string{"noise"},
+	},
+	"KSampler": {
+		Type: "denoise_latents",
+		Inputs: map[string]string{
+			"model":        "unet",
+			"positive":     "positive_conditioning",
+			"negative":     "negative_conditioning",
+			"latent_image": "noise",
+			"steps":        "steps",
+			"cfg":          "cfg_scale",
+			"sampler_name": "scheduler",
+			"denoise":      "denoising_end",
+		},
+		Outputs: []string{"latents"},
+	},
+	"VAEDecode": {
+		Type:    "l2i",
+		Inputs:  map[string]string{"samples": "latents", "vae": "vae"},
+		Outputs: []string{"image"},
+	},
+	"SaveImage": {
+		Type:    "save_image",
+		Inputs:  map[string]string{"images": "image"},
+		Outputs: []string{"image"},
+	},
+}
+
+type invokeAIEdgeEnd struct {
+	NodeID string `json:"node_id"`
+	Field  string `json:"field"`
+}
+
+type invokeAIEdge struct {
+	Source      invokeAIEdgeEnd `json:"source"`
+	Destination invokeAIEdgeEnd `json:"destination"`
+}
+
+type invokeAIGraph struct {
+	ID    string                            `json:"id"`
+	Nodes map[string]map[string]interface{} `json:"nodes"`
+	Edges []invokeAIEdge                    `json:"edges"`
+}
+
+// ExportToInvokeAI 将 ComfyUI API 格式工作流转换为 InvokeAI 的 graph JSON；
+// 连线转为 edges，字面量输入写入节点字段，遇到转换表外的节点返回 ErrUnsupportedNode
+func ExportToInvokeAI(wf map[string]interface{}) ([]byte, error) {
+	ids := make([]string, 0, len(wf))
+	for id := range wf {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	graph := invokeAIGraph{ID: "huobao_drama_export", Nodes: make(map[string]map[string]interface{}, len(wf))}
+	for _, id := range ids {
+		node, _ := wf[id].(map[string]interface{})
+		classType, _ := node["class_type"].(string)
+		mapping, ok := invokeAINodes[classType]
+		if !ok {
+			return nil, fmt.Errorf("%w: node %s (%s)", ErrUnsupportedNode, id, classType)
+		}
+		out := map[string]interface{}{"id": id, "type": mapping.Type}
+		inputs, _ := node["inputs"].(map[string]interface{})
+		names := make([]string, 0, len(inputs))
+		for name := range inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			field, ok := mapping.Inputs[name]
+			if !ok {
+				continue
+			}
+			srcID, idx, isWire := parseWire(inputs[name])
+			if !isWire {
+				out[field] = inputs[name]
+				continue
+			}
+			srcNode, _ := wf[srcID].(map[string]interface{})
+			srcClass, _ := srcNode["class_type"].(string)
+			srcMapping, ok := invokeAINodes[srcClass]
+			if !ok {
+				return nil, fmt.Errorf("%w: node %s (%s)", ErrUnsupportedNode, srcID, srcClass)
+			}
+			if idx < 0 || idx >= len(srcMapping.Outputs) {
+				return nil, fmt.Errorf("comfyui export node %s input %q: output index %d out of range", id, name, idx)
+			}
+			graph.Edges = append(graph.Edges, invokeAIEdge{
+				Source:      invokeAIEdgeEnd{NodeID: srcID, Field: srcMapping.Outputs[idx]},
+				Destination: invokeAIEdgeEnd{NodeID: id, Field: field},
+			})
+		}
+		graph.Nodes[id] = out
+	}
+
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui marshal invokeai graph: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,128 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// JobStatus 异步任务的状态
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending" // 在队列中排队
+	JobRunning JobStatus = "running" // 正在执行
+	JobDone    JobStatus = "done"    // 已完成，可用 Result 取结果
+	JobFailed  JobStatus = "failed"  // 执行出错，Result 返回对应错误
+	// JobUnknown 既不在队列也不在 history 中：prompt_id 无效，或 ComfyUI 重启后丢失了内存中的队列与 history
+	JobUnknown JobStatus = "unknown"
+)
+
+var (
+	// ErrJobNotFinished 任务尚未完成，Result 暂无结果
+	ErrJobNotFinished = errors.New("comfyui job not finished")
+	// ErrJobNotFound 任务既不在队列也不在 history 中，见 JobUnknown
+	ErrJobNotFound = errors.New("comfyui job not found")
+)
+
+// Job Submit 返回的任务，PromptID 可持久化到任务表，进程重启后用 Status / WaitForResult 继续跟踪
+type Job struct {
+	PromptID string
+	// Seed 实际使用的种子（随机种子模式下为本次生成的值）
+	Seed int64
+}
+
+// Submit 构建并提交工作流后立即返回，不等待执行；Params 的处理与 Generate 相同，
+// 但 SafetyCheck 与 ValidateResult 只在同步生成时生效
+func (c *Client) Submit(ctx context.Context, p *Params) (*Job, error) {
+	c.applyDefaultParams(p)
+	ctx = withRequestID(ctx, p.RequestID)
+	workflow, err := c.prepareWorkflow(ctx, p, nil)
+	if err != nil {
+		return nil, c.localizeError(err)
+	}
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, c.localizeError(err)
+	}
+	return &Job{PromptID: promptID, Seed: p.Seed}, nil
+}
+
+// Status 依次查询 /queue 与 /history 判断任务状态；先查队列，任务在两次请求之间完成时也能在 history 中查到
+func (c *Client) Status(ctx context.Context, promptID string) (JobStatus, error) {
+	position, running, err := c.QueuePosition(ctx, promptID)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case running:
+		return JobRunning, nil
+	case position >= 0:
+		return JobPending, nil
+	}
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return JobUnknown, nil
+	}
+	if entry.Status.ExecutionError() != nil || len(entry.NodeErrors) > 0 {
+		return JobFailed, nil
+	}
+	return JobDone, nil
+}
+
+// Result 返回已完成任务全部输出节点的图片 URL（按节点 ID 顺序，与 Generate 的 ImageURLs 一致）；未完成时返回 ErrJobNotFinished，执行失败时返回 *ExecutionError 或 *NodeExecutionError
+func (c *Client) Result(ctx context.Context, promptID string) ([]string, error) {
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFinished, promptID)
+	}
+	if execErr := entry.Status.ExecutionError(); execErr != nil {
+		return nil, execErr
+	}
+	if len(entry.NodeErrors) > 0 {
+		return nil, &NodeExecutionError{NodeErrors: entry.NodeErrors}
+	}
+	imgs := entry.outputImages(allOutputNodes)
+	if len(imgs) == 0 {
+		return nil, fmt.Errorf("%w: prompt %s", ErrNoOutput, promptID)
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(imgs))
+	for _, img := range imgs {
+		imageURL, err := historyImageURL(baseURL, img)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, imageURL)
+	}
+	return urls, nil
+}
+
+// WaitForResult 按 PollInterval 轮询 Status 直到任务结束并返回 Result；只受 ctx 限制（不应用 PollTimeout），
+// 适合重启后恢复已排队很久的任务。任务丢失时返回 ErrJobNotFound
+func (c *Client) WaitForResult(ctx context.Context, promptID string) ([]string, error) {
+	for {
+		status, err := c.Status(ctx, promptID)
+		if err != nil && !errors.Is(err, ErrRateLimited) {
+			return nil, err
+		}
+		switch status {
+		case JobDone, JobFailed:
+			return c.Result(ctx, promptID)
+		case JobUnknown:
+			return nil, fmt.Errorf("%w: %s", ErrJobNotFound, promptID)
+		}
+		if err := sleepCtx(ctx, c.pollEvery()); err != nil {
+			return nil, err
+		}
+	}
+}
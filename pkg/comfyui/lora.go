@@ -0,0 +1,45 @@
+package comfyui
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LoRA 叠加到模型上的 LoRA 及权重
+type LoRA struct {
+	Name     string  `json:"name" yaml:"name"`
+	Strength float64 `json:"strength" yaml:"strength"`
+}
+
+// loraBaseNodeID LoraLoaderModelOnly 节点的起始 ID，第 i 个为 70+i
+const loraBaseNodeID = 70
+
+// sortedLoRAs 按名称（同名按权重）排序的副本
+func sortedLoRAs(loras []LoRA) []LoRA {
+	if len(loras) == 0 {
+		return nil
+	}
+	out := append([]LoRA(nil), loras...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Strength < out[j].Strength
+	})
+	return out
+}
+
+// addLoRAs 在 UNETLoader(17) 与 KSampler(15) 之间串联 LoraLoaderModelOnly(70..)
+func addLoRAs(workflow map[string]interface{}, loras []LoRA) {
+	sampler := workflow["15"].(map[string]interface{})["inputs"].(map[string]interface{})
+	model := sampler["model"]
+	for i, l := range sortedLoRAs(loras) {
+		id := fmt.Sprintf("%d", loraBaseNodeID+i)
+		workflow[id] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"model": model, "lora_name": l.Name, "strength_model": l.Strength},
+			"class_type": "LoraLoaderModelOnly",
+		}
+		model = []interface{}{id, 0}
+	}
+	sampler["model"] = model
+}
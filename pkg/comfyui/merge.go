@@ -0,0 +1,106 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MergeParams 两个 UNET 模型按比例混合后生图；Params 为出图参数（其中 UNETModelName 会被 ModelA 覆盖）
+type MergeParams struct {
+	Params
+	ModelA string  // 对应 ModelMergeSimple 的 model1
+	ModelB string  // 对应 ModelMergeSimple 的 model2
+	Ratio  float64 // model1 的权重，1 表示完全使用 ModelA
+}
+
+func (mp *MergeParams) validate() error {
+	if mp.ModelA == "" || mp.ModelB == "" {
+		return fmt.Errorf("comfyui merge requires two model names")
+	}
+	if mp.Ratio < 0 || mp.Ratio > 1 {
+		return fmt.Errorf("comfyui merge ratio must be within [0, 1], got %v", mp.Ratio)
+	}
+	return nil
+}
+
+// MergeModels 在 flux.json 工作流基础上增加第二个 UNETLoader(40) 与 ModelMergeSimple(41)，
+// KSampler 使用混合后的模型出图，返回图片 URL；无需重新训练即可混合两个角色模型
+func (c *Client) MergeModels(ctx context.Context, params MergeParams) (string, error) {
+	if err := params.validate(); err != nil {
+		return "", err
+	}
+	params.UNETModelName = params.ModelA
+	return c.generate(ctx, &params.Params, func(workflow map[string]interface{}) error {
+		// model1 取采样器当前的模型（已串联 Params.LoRAs），LoRA 作用于 ModelA 后再与 ModelB 混合
+		model, err := samplerModel(workflow)
+		if err != nil {
+			return err
+		}
+		addModelMerge(workflow, model, params.ModelB, params.Ratio)
+		return setSamplerModel(workflow, []interface{}{"41", 0})
+	}, nil)
+}
+
+// addModelMerge 添加 UNETLoader(40) 与 ModelMergeSimple(41)，把 model1（节点 17 或其后的 LoRA 链）与 ModelB 混合
+func addModelMerge(workflow map[string]interface{}, model1 interface{}, modelB string, ratio float64) {
+	workflow["40"] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"unet_name": modelB, "weight_dtype": "fp8_e4m3fn"},
+		"class_type": "UNETLoader",
+	}
+	workflow["41"] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"model1": model1,
+			"model2": []interface{}{"40", 0},
+			"ratio":  ratio,
+		},
+		"class_type": "ModelMergeSimple",
+	}
+}
+
+// mergedCheckpointNodeID SaveMergedModel 中 CheckpointSave 节点的 ID
+const mergedCheckpointNodeID = "42"
+
+// SaveMergedModel 混合 ModelA 与 ModelB 后用 CheckpointSave 保存为 checkpoint（与 flux.json 相同的 CLIP、VAE），
+// savePath 为 filename_prefix，如 "checkpoints/drama_hero"；完成后确认文件已出现在 ListModels("checkpoints") 中，
+// 需要 ComfyUI 的 extra_model_paths 包含 output 目录，否则返回 ErrModelNotFound
+func (c *Client) SaveMergedModel(ctx context.Context, params MergeParams, savePath string) error {
+	if err := params.validate(); err != nil {
+		return err
+	}
+	if savePath == "" {
+		return fmt.Errorf("comfyui save merged model requires a save path")
+	}
+	params.UNETModelName = params.ModelA
+	full := c.buildWorkflow(&params.Params)
+	workflow := map[string]interface{}{"17": full["17"], "18": full["18"], "19": full["19"]}
+	addModelMerge(workflow, []interface{}{"17", 0}, params.ModelB, params.Ratio)
+	workflow[mergedCheckpointNodeID] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"model":           []interface{}{"41", 0},
+			"clip":            []interface{}{"18", 0},
+			"vae":             []interface{}{"19", 0},
+			"filename_prefix": savePath,
+		},
+		"class_type": "CheckpointSave",
+	}
+
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return err
+	}
+	if _, err := c.waitForEntry(ctx, promptID); err != nil {
+		return err
+	}
+	names, err := c.ListModels(ctx, "checkpoints")
+	if err != nil {
+		return err
+	}
+	want := strings.TrimPrefix(savePath, "checkpoints/")
+	for _, name := range names {
+		if strings.HasPrefix(strings.ReplaceAll(name, "\\", "/"), want) {
+			return nil
+		}
+	}
+	return ErrModelNotFound{ModelName: savePath, NodeID: mergedCheckpointNodeID}
+}
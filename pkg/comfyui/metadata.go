@@ -0,0 +1,33 @@
+package comfyui
+
+import "encoding/json"
+
+// metadataNodeID 保存 Params.Metadata 的 PrimitiveString 节点
+const metadataNodeID = "91"
+
+// addMetadataNode 把 Metadata 序列化为 JSON 写入 PrimitiveString 节点（与 trace.go 的 trace ID 节点相同，不连接输出、不会执行）。
+// 不能用 Note：它只存在于前端，后端校验 prompt 时会因找不到节点类型而拒绝整个工作流。
+// 提交的 prompt 会完整保存在 history 中，完成后 GetHistory 可从中读回
+func addMetadataNode(workflow map[string]interface{}, metadata map[string]string) {
+	text, _ := json.Marshal(metadata)
+	workflow[metadataNodeID] = map[string]interface{}{
+		"class_type": "PrimitiveString",
+		"inputs":     map[string]interface{}{"value": string(text)},
+		"_meta":      map[string]interface{}{"title": "metadata"},
+	}
+}
+
+// parseHistoryMetadata 从 history 记录的 prompt（[number, prompt_id, workflow, extra_data, outputs]）中取出 Metadata
+func parseHistoryMetadata(history map[string]interface{}) map[string]string {
+	node, _ := historyWorkflow(history)[metadataNodeID].(map[string]interface{})
+	if classType, _ := node["class_type"].(string); classType != "PrimitiveString" {
+		return nil
+	}
+	inputs, _ := node["inputs"].(map[string]interface{})
+	text, _ := inputs["value"].(string)
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(text), &metadata); err != nil {
+		return nil
+	}
+	return metadata
+}
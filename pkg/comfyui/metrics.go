@@ -0,0 +1,82 @@
+package comfyui
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 显存指标，instance 标签为 Client.BaseURL，注册到 prometheus 默认 Registry
+var (
+	vramFreeBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "comfyui_vram_free_bytes",
+		Help: "ComfyUI 所有设备的空闲显存（字节），来自 /system_stats",
+	}, []string{"instance"})
+	vramUsedBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "comfyui_vram_used_bytes",
+		Help: "ComfyUI 所有设备的已用显存（字节），来自 /system_stats",
+	}, []string{"instance"})
+)
+
+// StartHealthMonitor 在后台每隔 interval 请求一次 /system_stats：更新 HealthCheck 状态（供 /livez 使用），
+// 并写入 comfyui_vram_free_bytes / comfyui_vram_used_bytes 指标；设置了 OnQueueDepthChanged 时同时请求 /queue。ctx 结束后停止
+func (c *Client) StartHealthMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthCheckTTL / 2
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.monitorOnce(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (c *Client) monitorOnce(ctx context.Context) {
+	stats, err := c.GetSystemStats(ctx)
+	if err != nil {
+		c.warnw("ComfyUI health check failed", "error", err)
+		return
+	}
+	c.health.lastOK.Store(time.Now().UnixNano())
+	var free, total int64
+	for _, d := range stats.Devices {
+		free += d.VRAMFree
+		total += d.VRAMTotal
+	}
+	vramFreeBytes.WithLabelValues(c.BaseURL).Set(float64(free))
+	vramUsedBytes.WithLabelValues(c.BaseURL).Set(float64(total - free))
+	if c.OnQueueDepthChanged != nil {
+		c.checkQueueDepth(ctx)
+	}
+}
+
+// checkQueueDepth 队列深度相对上次通知变化超过 10%（从 0 变为非 0 也算）时调用 OnQueueDepthChanged
+func (c *Client) checkQueueDepth(ctx context.Context) {
+	queue, err := c.QueueStatus(ctx)
+	if err != nil {
+		c.warnw("ComfyUI queue status failed", "error", err)
+		return
+	}
+	depth := int64(queue.RunningCount + queue.PendingCount)
+	if last := c.health.queueDepth.Load(); last > 0 {
+		prev := last - 1
+		diff := depth - prev
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff == 0 || diff*10 <= prev {
+			return
+		}
+	}
+	c.health.queueDepth.Store(depth + 1)
+	c.OnQueueDepthChanged(int(depth))
+}
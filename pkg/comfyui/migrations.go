@@ -0,0 +1,120 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNoMigrationPath 找不到从 fromVersion 升级到 toVersion 的迁移链
+var ErrNoMigrationPath = errors.New("comfyui no migration path")
+
+// MigrationWarning 迁移过程中无法自动处理、需要人工确认的问题
+type MigrationWarning struct {
+	NodeID  string
+	Message string
+}
+
+// Migration 就地修改工作流的单步迁移
+type Migration func(wf map[string]interface{}) ([]MigrationWarning, error)
+
+type migrationStep struct {
+	to         string
+	migrations []Migration
+}
+
+var (
+	migrationsMu sync.RWMutex
+	// migrations fromVersion -> 可升级到的下一版本
+	migrations = map[string]migrationStep{}
+)
+
+func init() {
+	RegisterMigration("0.1", "0.2", RenameNodeClass("OldSampleCustom", "SamplerCustomAdvanced"))
+}
+
+// RegisterMigration 注册 fromVersion -> toVersion 的迁移；每个 fromVersion 只保留一条出边，
+// MigrateWorkflow 沿出边链式执行直到到达目标版本
+func RegisterMigration(fromVersion, toVersion string, m ...Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	migrations[fromVersion] = migrationStep{to: toVersion, migrations: m}
+}
+
+// MigrateWorkflow 将旧版本 ComfyUI 的工作流升级到 toVersion，返回新工作流（不修改入参）与迁移警告
+func MigrateWorkflow(wf map[string]interface{}, fromVersion, toVersion string) (map[string]interface{}, []MigrationWarning, error) {
+	migrationsMu.RLock()
+	var chain []migrationStep
+	seen := map[string]bool{}
+	for v := fromVersion; v != toVersion; {
+		step, ok := migrations[v]
+		if !ok || seen[v] {
+			migrationsMu.RUnlock()
+			return nil, nil, fmt.Errorf("%w: %s -> %s", ErrNoMigrationPath, fromVersion, toVersion)
+		}
+		seen[v] = true
+		chain = append(chain, step)
+		v = step.to
+	}
+	migrationsMu.RUnlock()
+
+	out, _ := deepCopyValue(wf).(map[string]interface{})
+	var warnings []MigrationWarning
+	for _, step := range chain {
+		for _, m := range step.migrations {
+			w, err := m(out)
+			if err != nil {
+				return nil, warnings, fmt.Errorf("comfyui migrate to %s: %w", step.to, err)
+			}
+			warnings = append(warnings, w...)
+		}
+	}
+	return out, warnings, nil
+}
+
+// RenameNodeClass 把 class_type 为 oldClass 的节点改名为 newClass；输入名不变，
+// 新节点输入不一致时需要另外注册迁移
+func RenameNodeClass(oldClass, newClass string) Migration {
+	return func(wf map[string]interface{}) ([]MigrationWarning, error) {
+		ids := make([]string, 0, len(wf))
+		for id := range wf {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		var warnings []MigrationWarning
+		for _, id := range ids {
+			node, _ := wf[id].(map[string]interface{})
+			if classType, _ := node["class_type"].(string); classType != oldClass {
+				continue
+			}
+			node["class_type"] = newClass
+			warnings = append(warnings, MigrationWarning{
+				NodeID:  id,
+				Message: fmt.Sprintf("renamed %s to %s, check inputs", oldClass, newClass),
+			})
+		}
+		return warnings, nil
+	}
+}
+
+// deepCopyValue 复制 JSON 风格的 map/slice 嵌套结构
+func deepCopyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[k] = deepCopyValue(val)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, val := range t {
+			s[i] = deepCopyValue(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,43 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ModelAliasRegistry 友好模型名到 ComfyUI 模型路径的映射，如 "flux-dev" -> "flux\\flux1-dev.safetensors"
+type ModelAliasRegistry struct {
+	aliases map[string]string
+}
+
+// LoadModelAliases 读取 JSON 对象格式的别名文件：{"flux-dev": "flux\\flux1-dev.safetensors"}
+func LoadModelAliases(path string) (*ModelAliasRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	aliases := map[string]string{}
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("comfyui parse model aliases %s: %w", path, err)
+	}
+	return &ModelAliasRegistry{aliases: aliases}, nil
+}
+
+// ResolveModelName 返回别名对应的模型路径，未登记的名称原样返回
+func (r *ModelAliasRegistry) ResolveModelName(alias string) string {
+	if r == nil {
+		return alias
+	}
+	if name, ok := r.aliases[alias]; ok {
+		return name
+	}
+	return alias
+}
+
+func startsWithLetter(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r)
+}
@@ -0,0 +1,105 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// ErrModelNotFound 工作流引用的模型在目标服务器上不存在
+type ErrModelNotFound struct {
+	ModelName string
+	NodeID    string
+}
+
+func (e ErrModelNotFound) Error() string {
+	return fmt.Sprintf("comfyui model %q (node %s) not found on server", e.ModelName, e.NodeID)
+}
+
+// modelLoaderInputs 加载器节点 -> 模型输入名 -> ComfyUI 模型目录
+var modelLoaderInputs = map[string]map[string]string{
+	"CheckpointLoaderSimple": {"ckpt_name": "checkpoints"},
+	"UNETLoader":             {"unet_name": "diffusion_models"},
+	"VAELoader":              {"vae_name": "vae"},
+	"DualCLIPLoader":         {"clip_name1": "text_encoders", "clip_name2": "text_encoders"},
+	"CLIPLoader":             {"clip_name": "text_encoders"},
+	"LoraLoader":             {"lora_name": "loras"},
+	"LoraLoaderModelOnly":    {"lora_name": "loras"},
+}
+
+// ListModels 查询 /models/{folder}，返回该目录下的模型文件名
+func (c *Client) ListModels(ctx context.Context, folder string) ([]string, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/models/"+url.PathEscape(folder), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("comfyui models/%s %s", folder, resp.Status)
+	}
+	var names []string
+	if err := json.NewDecoder(resp.Body).Decode(&names); err != nil {
+		return nil, fmt.Errorf("comfyui decode models: %w", err)
+	}
+	return names, nil
+}
+
+// EnsureModelsLoaded 检查工作流中各加载器节点引用的模型是否都在服务器上，
+// 缺失的每个模型对应一个 ErrModelNotFound（errors.Join 合并，可用 errors.As 取出）
+func (c *Client) EnsureModelsLoaded(ctx context.Context, wf map[string]interface{}) error {
+	ids := make([]string, 0, len(wf))
+	for id := range wf {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	available := map[string]map[string]bool{}
+	var errs []error
+	for _, id := range ids {
+		node, _ := wf[id].(map[string]interface{})
+		classType, _ := node["class_type"].(string)
+		loader, ok := modelLoaderInputs[classType]
+		if !ok {
+			continue
+		}
+		inputs, _ := node["inputs"].(map[string]interface{})
+		names := make([]string, 0, len(loader))
+		for name := range loader {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, input := range names {
+			model, _ := inputs[input].(string)
+			if model == "" {
+				continue
+			}
+			folder := loader[input]
+			if available[folder] == nil {
+				list, err := c.ListModels(ctx, folder)
+				if err != nil {
+					return err
+				}
+				available[folder] = make(map[string]bool, len(list))
+				for _, m := range list {
+					available[folder][m] = true
+				}
+			}
+			if !available[folder][model] {
+				errs = append(errs, ErrModelNotFound{ModelName: model, NodeID: id})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
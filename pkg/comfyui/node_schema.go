@@ -0,0 +1,170 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// FieldDef 节点输入字段的定义
+type FieldDef struct {
+	Type        string      `json:"type"` // INT / FLOAT / STRING / BOOLEAN，或连线类型如 MODEL、CLIP、IMAGE
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// NodeSchema 节点的输入定义与输出类型
+type NodeSchema struct {
+	Inputs  map[string]FieldDef
+	Outputs []string
+}
+
+func req(t string) FieldDef { return FieldDef{Type: t, Required: true} }
+
+// builtinNodeSchemas ComfyUI 自带、本包工作流用到的常见节点
+var builtinNodeSchemas = map[string]NodeSchema{
+	"CLIPTextEncode":      {Inputs: map[string]FieldDef{"text": req("STRING"), "clip": req("CLIP")}, Outputs: []string{"CONDITIONING"}},
+	"ConditioningZeroOut": {Inputs: map[string]FieldDef{"conditioning": req("CONDITIONING")}, Outputs: []string{"CONDITIONING"}},
+	"EmptyLatentImage": {Inputs: map[string]FieldDef{
+		"width": req("INT"), "height": req("INT"), "batch_size": {Type: "INT", Default: 1},
+	}, Outputs: []string{"LATENT"}},
+	"KSampler": {Inputs: map[string]FieldDef{
+		"model": req("MODEL"), "positive": req("CONDITIONING"), "negative": req("CONDITIONING"), "latent_image": req("LATENT"),
+		"seed": req("INT"), "steps": req("INT"), "cfg": req("FLOAT"), "sampler_name": req("STRING"), "scheduler": req("STRING"),
+		"denoise": {Type: "FLOAT", Default: 1.0},
+	}, Outputs: []string{"LATENT"}},
+	"UNETLoader":     {Inputs: map[string]FieldDef{"unet_name": req("STRING"), "weight_dtype": {Type: "STRING", Default: "default"}}, Outputs: []string{"MODEL"}},
+	"DualCLIPLoader": {Inputs: map[string]FieldDef{"clip_name1": req("STRING"), "clip_name2": req("STRING"), "type": req("STRING"), "device": {Type: "STRING", Default: "default"}}, Outputs: []string{"CLIP"}},
+	"VAELoader":      {Inputs: map[string]FieldDef{"vae_name": req("STRING")}, Outputs: []string{"VAE"}},
+	"VAEDecode":      {Inputs: map[string]FieldDef{"samples": req("LATENT"), "vae": req("VAE")}, Outputs: []string{"IMAGE"}},
+	"VAEEncode":      {Inputs: map[string]FieldDef{"pixels": req("IMAGE"), "vae": req("VAE")}, Outputs: []string{"LATENT"}},
+	"LoadImage":      {Inputs: map[string]FieldDef{"image": req("STRING")}, Outputs: []string{"IMAGE", "MASK"}},
+	"SaveImage":      {Inputs: map[string]FieldDef{"images": req("IMAGE"), "filename_prefix": {Type: "STRING", Default: "ComfyUI"}}},
+	"PreviewImage":   {Inputs: map[string]FieldDef{"images": req("IMAGE")}},
+	"LoraLoader": {Inputs: map[string]FieldDef{
+		"model": req("MODEL"), "clip": req("CLIP"), "lora_name": req("STRING"), "strength_model": req("FLOAT"), "strength_clip": req("FLOAT"),
+	}, Outputs: []string{"MODEL", "CLIP"}},
+	"ModelMergeSimple": {Inputs: map[string]FieldDef{"model1": req("MODEL"), "model2": req("MODEL"), "ratio": req("FLOAT")}, Outputs: []string{"MODEL"}},
+	"ImageCrop":        {Inputs: map[string]FieldDef{"image": req("IMAGE"), "width": req("INT"), "height": req("INT"), "x": req("INT"), "y": req("INT")}, Outputs: []string{"IMAGE"}},
+}
+
+// RegisterCustomNode 为本 builder 登记第三方插件节点（如 AnimateDiff-Evolved），Validate 时按此校验输入
+func (b *WorkflowBuilder) RegisterCustomNode(classType string, inputSchema map[string]FieldDef, outputTypes []string) {
+	if b.custom == nil {
+		b.custom = make(map[string]NodeSchema)
+	}
+	b.custom[classType] = NodeSchema{Inputs: inputSchema, Outputs: outputTypes}
+}
+
+// schema 查找节点定义，RegisterCustomNode 登记的优先于内置定义
+func (b *WorkflowBuilder) schema(classType string) (NodeSchema, bool) {
+	if s, ok := b.custom[classType]; ok {
+		return s, true
+	}
+	s, ok := builtinNodeSchemas[classType]
+	return s, ok
+}
+
+// Validate 校验所有节点：类型已知、必填输入齐全、没有未定义的输入、连线指向的输出序号存在
+func (b *WorkflowBuilder) Validate() error {
+	ids := make([]string, 0, len(b.nodes))
+	for id := range b.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		node := b.nodes[id]
+		classType, _ := node["class_type"].(string)
+		inputs, _ := node["inputs"].(map[string]interface{})
+		schema, ok := b.schema(classType)
+		if !ok {
+			errs = append(errs, fmt.Errorf("node %s: unknown class_type %q, register it with RegisterCustomNode", id, classType))
+			continue
+		}
+		for name, def := range schema.Inputs {
+			if _, ok := inputs[name]; !ok && def.Required {
+				errs = append(errs, fmt.Errorf("node %s (%s): missing required input %q", id, classType, name))
+			}
+		}
+		for name, v := range inputs {
+			if _, ok := schema.Inputs[name]; !ok {
+				errs = append(errs, fmt.Errorf("node %s (%s): unknown input %q", id, classType, name))
+				continue
+			}
+			srcID, idx, ok := parseWire(v)
+			if !ok {
+				continue
+			}
+			src, ok := b.nodes[srcID]
+			if !ok {
+				errs = append(errs, fmt.Errorf("node %s (%s): input %q references missing node %s", id, classType, name, srcID))
+				continue
+			}
+			srcClass, _ := src["class_type"].(string)
+			if srcSchema, ok := b.schema(srcClass); ok && idx >= len(srcSchema.Outputs) {
+				errs = append(errs, fmt.Errorf("node %s (%s): input %q references output %d of %s which has %d outputs", id, classType, name, idx, srcClass, len(srcSchema.Outputs)))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Schema 工作流中可调参数的定义，键为 "<节点ID>.<输入名>"
+type Schema struct {
+	Fields map[string]FieldDef `json:"fields"`
+}
+
+// WorkflowSchema 从工作流各节点的字面量输入（连线输入除外）提取参数定义，供前端自动生成表单：
+// Default 为工作流中的当前值，Type 优先取内置节点定义、否则按值推断，Description 取节点 _meta.title
+func WorkflowSchema(wf map[string]interface{}) (*Schema, error) {
+	schema := &Schema{Fields: make(map[string]FieldDef)}
+	for id, raw := range wf {
+		node, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("comfyui workflow schema: node %s is not an object", id)
+		}
+		classType, _ := node["class_type"].(string)
+		inputs, ok := node["inputs"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("comfyui workflow schema: node %s has no inputs", id)
+		}
+		title := classType
+		if meta, ok := node["_meta"].(map[string]interface{}); ok {
+			if t, _ := meta["title"].(string); t != "" {
+				title = t
+			}
+		}
+		known := builtinNodeSchemas[classType].Inputs
+		for name, v := range inputs {
+			if _, _, isWire := parseWire(v); isWire {
+				continue
+			}
+			def := FieldDef{Type: inferFieldType(v), Default: v, Description: title + " " + name}
+			if k, ok := known[name]; ok {
+				def.Type, def.Required = k.Type, k.Required
+			}
+			schema.Fields[id+"."+name] = def
+		}
+	}
+	return schema, nil
+}
+
+func inferFieldType(v interface{}) string {
+	switch n := v.(type) {
+	case string:
+		return "STRING"
+	case bool:
+		return "BOOLEAN"
+	case int, int64:
+		return "INT"
+	case float64:
+		if n == float64(int64(n)) {
+			return "INT"
+		}
+		return "FLOAT"
+	}
+	return "JSON"
+}
@@ -0,0 +1,163 @@
+package comfyui
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"golang.org/x/net/http2"
+)
+
+// Option 配置 Client 的可选项
+type Option func(*Client)
+
+// NewClient 创建 ComfyUI 客户端；也可以直接使用 &Client{BaseURL: ...}
+func NewClient(baseURL string, opts ...Option) *Client {
+	// 无法规范化时保留原值，首次请求时返回 ErrInvalidBaseURL
+	if normalized, err := normalizeBaseURL(baseURL); err == nil {
+		baseURL = normalized
+	}
+	c := &Client{BaseURL: baseURL, UseWebSocketIfAvailable: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// setTransport 替换底层 Transport，保留已有 http.Client 的其它设置
+func (c *Client) setTransport(rt http.RoundTripper) {
+	if c.HTTP == nil {
+		c.HTTP = &http.Client{Timeout: 30 * time.Second}
+	}
+	c.HTTP.Transport = rt
+}
+
+func WithBudget(bt BudgetTracker) Option {
+	return func(c *Client) {
+		c.budget = bt
+	}
+}
+
+// WithHTTP2 使用优先协商 HTTP/2 的 Transport，适合挂在支持 HTTP/2 的反向代理之后、并发提交较多的场景
+func WithHTTP2() Option {
+	return func(c *Client) {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.ForceAttemptHTTP2 = true
+		// Clone 后 TLSNextProto 可能非空，交给 http2.ConfigureTransport 重新注册 h2
+		t.TLSNextProto = nil
+		if err := http2.ConfigureTransport(t); err != nil {
+			return
+		}
+		c.setTransport(t)
+	}
+}
+
+// userAgentTransport 为每个请求设置固定的 User-Agent
+type userAgentTransport struct {
+	ua   string
+	base http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+	return t.base.RoundTrip(req)
+}
+
+// WithUserAgent 所有请求使用自定义 User-Agent（默认 "huobao-drama/<Version>"），便于在 ComfyUI 访问日志中区分调用方；
+// 包装当前的 Transport，需放在 WithHTTP2 等替换 Transport 的选项之后
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		base := http.DefaultTransport
+		if c.HTTP != nil && c.HTTP.Transport != nil {
+			base = c.HTTP.Transport
+		}
+		c.setTransport(&userAgentTransport{ua: ua, base: base})
+	}
+}
+
+// WithResponseCache 在内存中缓存 GET /system_stats、/object_info、/queue、/models 的响应 ttl 时长，
+// 适合频繁调用 ListModels 填充下拉框的场景；/prompt、/upload/image 等写操作从不缓存，Cancel 与 Status 查询队列时也不走缓存。
+// 包装当前的 Transport，需放在 WithHTTP2 等替换 Transport 的选项之后
+func WithResponseCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		base := http.DefaultTransport
+		if c.HTTP != nil && c.HTTP.Transport != nil {
+			base = c.HTTP.Transport
+		}
+		c.setTransport(&cachingTransport{ttl: ttl, base: base, entries: make(map[string]cachedResponse)})
+	}
+}
+
+// WithUnixSocket 通过 Unix 域套接字连接同容器 / 同 Pod 内的 ComfyUI（或其前置代理），
+// 请求的 Host 为 localhost；BaseURL 为空时设为 http://localhost。/ws 不经过该套接字，探测失败后自动退化为轮询
+func WithUnixSocket(socketPath string) Option {
+	return func(c *Client) {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = nil
+		t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		}
+		if c.BaseURL == "" {
+			c.BaseURL = "http://localhost"
+		}
+		c.setTransport(&hostTransport{host: "localhost", base: t})
+	}
+}
+
+// hostTransport 固定请求的 Host 头
+type hostTransport struct {
+	host string
+	base http.RoundTripper
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Host = t.host
+	return t.base.RoundTrip(req)
+}
+
+// WithThumbnail 让 DownloadImage 返回最长边不超过 maxSize 的 PNG 缩略图，
+// 最近使用的 cacheSize 张缓存在内存中，列表页反复加载同一张图时不再重复下载与缩放
+func WithThumbnail(maxSize, cacheSize int) Option {
+	return func(c *Client) {
+		c.thumbnail = &thumbnailConfig{maxSize: maxSize, cache: NewThumbnailCache(cacheSize)}
+	}
+}
+
+// WithWorkflowTemplate 注册工作流模板，Params.Template 为 t.Name 时 Generate 使用它；同名时后注册的生效
+func WithWorkflowTemplate(t *WorkflowTemplate) Option {
+	return func(c *Client) {
+		if c.templates == nil {
+			c.templates = make(map[string]*WorkflowTemplate)
+		}
+		c.templates[t.Name] = t
+	}
+}
+
+// WithW3CTracing 按 W3C Trace Context 规范把 ctx 中的 span 上下文写入所有请求的 traceparent / tracestate 头，
+// 供 Zipkin 等非 OTel 的追踪系统在 ComfyUI 前置代理处串联调用链；ctx 中没有有效 span 时不添加。
+// 包装当前的 Transport，需放在 WithHTTP2 等替换 Transport 的选项之后
+func WithW3CTracing() Option {
+	return func(c *Client) {
+		base := http.DefaultTransport
+		if c.HTTP != nil && c.HTTP.Transport != nil {
+			base = c.HTTP.Transport
+		}
+		c.setTransport(&w3cTraceTransport{base: base})
+	}
+}
+
+// w3cTraceTransport 用 propagation.TraceContext 注入 traceparent / tracestate
+type w3cTraceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *w3cTraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,44 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ExecuteParallel 把 subgraphs[i] 提交到 backends[i] 并同时等待，返回每个子图第一张输出图片的 URL（与 subgraphs 顺序一致）；
+// 适合角色与背景分别在不同 ComfyUI 实例上生成、之后再合成的场景。任一子图失败时取消其余子图并返回该错误
+func ExecuteParallel(ctx context.Context, subgraphs []map[string]interface{}, backends []*Client) ([]string, error) {
+	if len(subgraphs) != len(backends) {
+		return nil, fmt.Errorf("comfyui parallel: %d subgraphs but %d backends", len(subgraphs), len(backends))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	urls := make([]string, len(subgraphs))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := range subgraphs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			imageURL, err := backends[i].GenerateFromWorkflow(ctx, subgraphs[i])
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("comfyui parallel subgraph %d (%s): %w", i, backends[i].BaseURL, err)
+					cancel()
+				})
+				return
+			}
+			urls[i] = imageURL
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return urls, nil
+}
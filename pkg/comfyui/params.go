@@ -0,0 +1,194 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Params 文生图参数
+type Params struct {
+	Prompt string `json:"prompt" yaml:"prompt"`
+	// NegativePrompt 非空时编码为负向条件代替 ConditioningZeroOut；Flux 在 CFG 为 1 时不使用负向条件，需配合 CFG > 1；使用 AdvancedSampler 时不生效
+	NegativePrompt string `json:"negative_prompt,omitempty" yaml:"negative_prompt,omitempty"`
+	// BatchSize 一次生成的候选张数，默认 1，结果见 GenerateResult.ImageURLs；图生图时不生效
+	BatchSize int   `json:"batch_size,omitempty" yaml:"batch_size,omitempty"`
+	Width     int   `json:"width" yaml:"width"`   // 默认 1920（宽）
+	Height    int   `json:"height" yaml:"height"` // 默认 1080（高）
+	Seed      int64 `json:"seed" yaml:"seed"`
+	// SeedMode 为 random 时每次生成都重新随机种子；Seed 为 0 时同样视为随机
+	SeedMode SeedMode `json:"seed_mode,omitempty" yaml:"seed_mode,omitempty"`
+	// 采样参数（Steps 默认 25、CFG 默认 1 等），未填写的字段可由 SamplerPreset 补齐
+	SamplerConfig `yaml:",inline"`
+	SamplerPreset string `json:"sampler_preset" yaml:"sampler_preset"` // fast / quality / flux，见 samplerPresets
+	// 可选：百度翻译 API（工作流含 BaiduTranslateNode 时使用，为空则不走翻译）
+	BaiduTranslateAppID  string `json:"baidu_translate_app_id" yaml:"baidu_translate_app_id"`
+	BaiduTranslateAppKey string `json:"baidu_translate_app_key" yaml:"baidu_translate_app_key"`
+	// SeedInFilename 为 true 时输出文件名前缀为 drama_<seed>，便于从文件追溯生成参数
+	SeedInFilename bool `json:"seed_in_filename" yaml:"seed_in_filename"`
+	// UNETModelName UNETLoader 使用的模型，为空时为 DefaultUNETModel；可填 Client.ModelAliases 中的别名
+	UNETModelName string `json:"unet_model_name" yaml:"unet_model_name"`
+	// AdvancedSampler 非空时用 SamplerCustomAdvanced 替换 KSampler，可精确控制噪声、引导与 sigmas
+	AdvancedSampler *AdvancedSamplerConfig `json:"advanced_sampler,omitempty" yaml:"advanced_sampler,omitempty"`
+	// ModelFamily 非空时（flux / sdxl / sd15）Generate 先用 NormalizeForModel 调整 CFG 与步数
+	ModelFamily string `json:"model_family,omitempty" yaml:"model_family,omitempty"`
+	// DryRun 为 true 时 Generate 只对照 /object_info 在本地校验工作流、不提交执行，结果见 GenerateResult.DryRun
+	DryRun bool `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+	// Metadata 随工作流写入 PrimitiveString 节点的业务信息（场景 ID、角色、集数等），完成后可从 HistoryEntry.Metadata 读回
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	// RequestID 业务请求 ID，非空时 client_id 为 "huobao_drama-<RequestID>"，可从 ComfyUI 日志追溯到具体请求；不计入 ParamsHash
+	RequestID string `json:"request_id,omitempty" yaml:"request_id,omitempty"`
+	// TenantID 租户 ID，配置了 WithQuotaManager 时按此扣减配额，为空则不扣减；不计入 ParamsHash
+	TenantID string `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+	// SafetyCheck 为 true 时在 VAEDecode 与 SaveImage 之间插入 SafetyCheckerClass 节点，被判定为 nsfw 时 Generate 返回 ErrNSFWContent，节点未给出判定时返回 ErrSafetyCheckUnavailable
+	SafetyCheck bool `json:"safety_check,omitempty" yaml:"safety_check,omitempty"`
+	// LoRAs 依次叠加到 UNET 模型上的 LoRA（LoraLoaderModelOnly），叠加结果与顺序无关，构建时按名称排序；MergeModels 中作用于 ModelA，再与 ModelB 混合
+	LoRAs []LoRA `json:"loras,omitempty" yaml:"loras,omitempty"`
+	// UNETShards 非空时用 ShardedUNETLoaderClass 按分片加载模型，忽略 UNETModelName；服务器没有该节点时 Generate 返回 ErrNodeUnavailable
+	UNETShards []string `json:"unet_shards,omitempty" yaml:"unet_shards,omitempty"`
+	// LocationStamp 非空时下载生成的 PNG 并写入带 GPS 坐标的 EXIF（eXIf 块），结果在 GenerateResult.ImageData 中
+	LocationStamp *GPSCoords `json:"location_stamp,omitempty" yaml:"location_stamp,omitempty"`
+	// Img2Img 非空时以其中的图片为起点生成（图生图），见 Img2ImgParams
+	Img2Img *Img2ImgParams `json:"img2img,omitempty" yaml:"img2img,omitempty"`
+	// WorkflowOverride 非空时提交该 API 格式工作流代替内置构建，字符串中的 {{seed}} {{width}} {{height}} {{steps}} {{cfg}} {{prompt}} 替换为参数值；
+	// 此时 LoRAs、AdvancedSampler 等依赖内置构建的字段不生效，设置 Img2Img 返回 ErrTemplateUnsupported
+	WorkflowOverride map[string]interface{} `json:"workflow_override,omitempty" yaml:"workflow_override,omitempty"`
+	// UseDefaultWorkflow 为 true 且 WorkflowOverride 为空时，使用内置的 defaults/flux_workflow.json 模板
+	UseDefaultWorkflow bool `json:"use_default_workflow,omitempty" yaml:"use_default_workflow,omitempty"`
+	// Template 非空时使用该名称的工作流模板（WithWorkflowTemplate 或配置文件注册，"flux" 为内置模板）代替内置构建，
+	// 优先于 WorkflowOverride；同样不支持 LoRAs、AdvancedSampler 等字段，设置 Img2Img 返回 ErrTemplateUnsupported；预算与配额检查照常
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// TemplateVars 模板的自定义绑定参数（如 SDXL 的 ckpt_name），同名时覆盖内置参数
+	TemplateVars map[string]interface{} `json:"template_vars,omitempty" yaml:"template_vars,omitempty"`
+}
+
+// SeedMode 种子模式
+type SeedMode string
+
+const (
+	SeedModeFixed  SeedMode = "fixed"
+	SeedModeRandom SeedMode = "random"
+)
+
+// isRandomSeed 本次生成是否使用随机种子
+func (p *Params) isRandomSeed() bool {
+	return p.SeedMode == SeedModeRandom || p.Seed == 0
+}
+
+// ParamsHash 计算 Params 规范化 JSON（键排序）的 SHA-256，可作为跨实例、跨重启稳定的缓存键；
+// 随机种子模式下种子按 0 计算，保证同一组参数得到相同的键
+func ParamsHash(p *Params) string {
+	cp := *p
+	if cp.SeedMode == SeedModeRandom {
+		cp.Seed = 0
+	}
+	cp.RequestID, cp.TenantID = "", ""
+	sum := sha256.Sum256(canonicalParamsJSON(&cp))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeepEqual 比较两组参数是否等价：nil 与空的切片 / map 视为相同，LoRAs 与顺序无关
+func DeepEqual(a, b *Params) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(canonicalParamsJSON(a), canonicalParamsJSON(b))
+}
+
+// canonicalParamsJSON Params 的规范化 JSON：LoRAs 排序，经 map 再序列化一次使键有序，与结构体字段顺序无关
+func canonicalParamsJSON(p *Params) []byte {
+	cp := *p
+	cp.LoRAs = sortedLoRAs(cp.LoRAs)
+	data, _ := json.Marshal(&cp)
+	var generic interface{}
+	_ = json.Unmarshal(data, &generic)
+	canonical, _ := json.Marshal(generic)
+	return canonical
+}
+
+// ParseParamsYAML 从 YAML（如 Kubernetes ConfigMap）解析 Params，未知字段视为错误
+func ParseParamsYAML(data []byte) (*Params, error) {
+	var p Params
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("comfyui parse params yaml: %w", err)
+	}
+	return &p, nil
+}
+
+// maxRemoteParamsBytes FetchFrom 读取的响应体上限
+const maxRemoteParamsBytes = 1 << 20
+
+// FetchFrom 从远程配置服务 GET 一份 JSON 参数并校验；p 中已有的字段作为默认值，
+// 响应中出现的字段覆盖之，返回新的 Params（p 本身不变），未知字段视为错误。
+// 使用带 30s 超时的默认 HTTP 客户端，需要 Client 的代理、TLS 等设置时用 Client.FetchParams
+func (p *Params) FetchFrom(ctx context.Context, paramsURL string) (*Params, error) {
+	return (&Client{}).FetchParams(ctx, p, paramsURL)
+}
+
+// FetchParams 同 Params.FetchFrom，经 c.HTTP 发出请求；响应体超过 1MB 时返回错误而不是截断解析
+func (c *Client) FetchParams(ctx context.Context, p *Params, paramsURL string) (*Params, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", paramsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui fetch params: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("comfyui fetch params %s", resp.Status)
+	}
+	// 多读 1 字节以区分“正好等于上限”和“超出上限”
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteParamsBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("comfyui fetch params: %w", err)
+	}
+	if len(body) > maxRemoteParamsBytes {
+		return nil, fmt.Errorf("comfyui fetch params: response exceeds %d bytes", maxRemoteParamsBytes)
+	}
+
+	out := *p
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return nil, fmt.Errorf("comfyui decode remote params: %w", err)
+	}
+	if err := out.validate(); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// validate 检查参数取值；零值字段由 applyDefaults 补全，不视为错误
+func (p *Params) validate() error {
+	if p.Prompt == "" {
+		return fmt.Errorf("comfyui params prompt is required")
+	}
+	if p.Width < 0 || p.Height < 0 {
+		return fmt.Errorf("comfyui params size must be positive, got %dx%d", p.Width, p.Height)
+	}
+	if p.Steps < 0 || p.CFG < 0 {
+		return fmt.Errorf("comfyui params steps and cfg must not be negative")
+	}
+	if p.SeedMode != "" && p.SeedMode != SeedModeFixed && p.SeedMode != SeedModeRandom {
+		return fmt.Errorf("comfyui unknown seed_mode %q", p.SeedMode)
+	}
+	if _, ok := modelFamilyDefaults[p.ModelFamily]; p.ModelFamily != "" && !ok {
+		return fmt.Errorf("comfyui unknown model_family %q", p.ModelFamily)
+	}
+	if _, ok := samplerPresets[p.SamplerPreset]; p.SamplerPreset != "" && !ok {
+		return fmt.Errorf("comfyui unknown sampler_preset %q", p.SamplerPreset)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package comfyui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseParamsYAML(t *testing.T) {
+	data, err := os.ReadFile("testdata/drama_config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParseParamsYAML(data)
+	if err != nil {
+		t.Fatalf("ParseParamsYAML: %v", err)
+	}
+	if p.Width != 1920 || p.Height != 1080 {
+		t.Errorf("size = %dx%d, want 1920x1080", p.Width, p.Height)
+	}
+	if p.Steps != 28 || p.Scheduler != "karras" || p.SamplerPreset != "quality" {
+		t.Errorf("sampler = %+v preset %q", p.SamplerConfig, p.SamplerPreset)
+	}
+
+	if _, err := ParseParamsYAML([]byte("promt: typo\n")); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestPatchParams(t *testing.T) {
+	base := &Params{Prompt: "城市夜景", Width: 1280, SamplerConfig: SamplerConfig{Steps: 25, Sampler: "euler"}}
+	patch := ParamsPatch(`{"steps": 30, "sampler": null, "prompt": "城市黎明"}`)
+	got, err := PatchParams(base, &patch)
+	if err != nil {
+		t.Fatalf("PatchParams: %v", err)
+	}
+	if got.Steps != 30 || got.Sampler != "" || got.Prompt != "城市黎明" || got.Width != 1280 {
+		t.Errorf("patched = %+v", got)
+	}
+	if base.Steps != 25 || base.Sampler != "euler" {
+		t.Errorf("base modified: %+v", base)
+	}
+}
+
+func TestParamsHash(t *testing.T) {
+	a := &Params{Prompt: "古镇雨巷", Width: 1024, Height: 576, SeedMode: SeedModeRandom, Seed: 123}
+	b := &Params{Prompt: "古镇雨巷", Width: 1024, Height: 576, SeedMode: SeedModeRandom, Seed: 456}
+	if ParamsHash(a) != ParamsHash(b) {
+		t.Error("random seed mode should not affect hash")
+	}
+	c := &Params{Prompt: "古镇雨巷", Width: 1024, Height: 576, Seed: 123}
+	d := &Params{Prompt: "古镇雨巷", Width: 1024, Height: 576, Seed: 456}
+	if ParamsHash(c) == ParamsHash(d) {
+		t.Error("fixed seeds should produce different hashes")
+	}
+}
+
+func TestBuildWorkflowDiff(t *testing.T) {
+	oldParams := &Params{Prompt: "a cat", Seed: 1}
+	oldParams.Steps = 25
+	newParams := &Params{Prompt: "a cat", Seed: 1}
+	newParams.Steps = 30
+
+	diffs, err := BuildWorkflowDiff(oldParams, newParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].ClassType != "KSampler" || len(diffs[0].Inputs) != 1 {
+		t.Fatalf("diffs = %+v", diffs)
+	}
+	if got := diffs[0].String(); !strings.HasPrefix(got, "KSampler.steps changed from 25 to 30") {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestParamsHashStability(t *testing.T) {
+	a := &Params{Prompt: "a cat", Seed: 7, LoRAs: []LoRA{{Name: "hero.safetensors", Strength: 0.8}, {Name: "ink.safetensors", Strength: 0.5}}}
+	b := &Params{Prompt: "a cat", Seed: 7, LoRAs: []LoRA{{Name: "ink.safetensors", Strength: 0.5}, {Name: "hero.safetensors", Strength: 0.8}}}
+	if ParamsHash(a) != ParamsHash(b) {
+		t.Error("LoRA order changed ParamsHash")
+	}
+	if !DeepEqual(a, b) {
+		t.Error("DeepEqual is sensitive to LoRA order")
+	}
+	if a.LoRAs[0].Name != "hero.safetensors" {
+		t.Error("ParamsHash reordered the caller's LoRAs")
+	}
+
+	empty := &Params{Prompt: "a cat", Seed: 7, LoRAs: []LoRA{}, Metadata: map[string]string{}}
+	none := &Params{Prompt: "a cat", Seed: 7}
+	if ParamsHash(empty) != ParamsHash(none) || !DeepEqual(empty, none) {
+		t.Error("nil and empty slices/maps should be equivalent")
+	}
+	if DeepEqual(a, none) {
+		t.Error("DeepEqual ignored LoRAs")
+	}
+}
+
+// TestFetchParams 经 Client.HTTP 请求远程参数（自签名证书只有 srv.Client() 信任），超出 1MB 的响应直接报错
+func TestFetchParams(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big" {
+			w.Write([]byte(`{"prompt":"` + strings.Repeat("长", maxRemoteParamsBytes) + `"}`))
+			return
+		}
+		w.Write([]byte(`{"prompt":"远程参数","steps":12}`))
+	}))
+	defer srv.Close()
+	c := &Client{HTTP: srv.Client()}
+
+	p, err := c.FetchParams(context.Background(), &Params{Width: 512}, srv.URL+"/params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Prompt != "远程参数" || p.Steps != 12 || p.Width != 512 {
+		t.Errorf("params = %+v", p)
+	}
+	if _, err := c.FetchParams(context.Background(), &Params{}, srv.URL+"/big"); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized response: err = %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ParamsPatch JSON Merge Patch（RFC 7396）文档，如 {"steps": 30, "prompt": null}
+type ParamsPatch json.RawMessage
+
+// PatchParams 将 patch 按 RFC 7396 语义合并到 base 上，返回新的 Params，base 不会被修改
+// 值为 null 的字段会被清除（回到零值，生成时再按默认值补齐）
+func PatchParams(base *Params, patch *ParamsPatch) (*Params, error) {
+	if base == nil {
+		base = &Params{}
+	}
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(baseJSON, &doc); err != nil {
+		return nil, err
+	}
+	if patch != nil && len(*patch) > 0 {
+		var patchDoc interface{}
+		if err := json.Unmarshal(*patch, &patchDoc); err != nil {
+			return nil, fmt.Errorf("comfyui invalid params patch: %w", err)
+		}
+		doc = mergePatch(doc, patchDoc)
+	}
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var out Params
+	if err := json.Unmarshal(merged, &out); err != nil {
+		return nil, fmt.Errorf("comfyui apply params patch: %w", err)
+	}
+	return &out, nil
+}
+
+// mergePatch RFC 7396 MergePatch 算法
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
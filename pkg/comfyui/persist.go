@@ -0,0 +1,102 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// resultStorageCategory WithResultStorage 上传时使用的 category
+const resultStorageCategory = "comfyui_results"
+
+// StoredAsset 持久化后的生成结果
+type StoredAsset struct {
+	URL         string // 存储后端返回的持久地址
+	Size        int64
+	SHA256      string // 内容的十六进制 SHA-256
+	ContentType string
+}
+
+// WithResultStorage Generate 成功后下载图片并上传到 storage，结果见 GenerateResult.Asset；
+// ComfyUI 的 output 目录被清理或 GPU 机器下线后，ImageURL 失效而 Asset.URL 仍可访问。上传失败时 Generate 返回错误。
+// 对象按内容命名（"<sha256>.<扩展名>"），ComfyUI 重启后复用文件名时不会覆盖旧结果
+func WithResultStorage(storage StorageBackend) Option {
+	return func(c *Client) {
+		c.resultStorage = storage
+	}
+}
+
+// PersistImage 下载 imageURL（须为 BaseURL 下的 /view 地址）并上传到 storage
+func (c *Client) PersistImage(ctx context.Context, imageURL string, storage StorageBackend) (*StoredAsset, error) {
+	data, _, err := c.downloadImage(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+	return storeAsset(storage, data, path.Base(cleanFilename(imageURL)))
+}
+
+// DownloadPromptImage 按 prompt_id 从 history 找到第一张输出图片并下载原图，返回内容、Content-Type 与 /view 地址
+func (c *Client) DownloadPromptImage(ctx context.Context, promptID string) ([]byte, string, string, error) {
+	urls, err := c.Result(ctx, promptID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	data, contentType, err := c.downloadImage(ctx, urls[0])
+	return data, contentType, urls[0], err
+}
+
+// storeAsset 以内容 SHA-256 加原文件扩展名作为文件名上传；ComfyUI 的文件名按计数器递增，重启或清理 output 后会重复
+func storeAsset(storage StorageBackend, data []byte, filename string) (*StoredAsset, error) {
+	assetURL, digest, err := uploadByHash(storage, data, filename, resultStorageCategory)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui store result: %w", err)
+	}
+	return &StoredAsset{
+		URL:         assetURL,
+		Size:        int64(len(data)),
+		SHA256:      digest,
+		ContentType: http.DetectContentType(data),
+	}, nil
+}
+
+// uploadByHash 以 "<sha256>.<原扩展名>" 为文件名上传 data，返回存储地址与十六进制摘要
+func uploadByHash(storage StorageBackend, data []byte, filename, category string) (string, string, error) {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	assetURL, err := storage.Upload(bytes.NewReader(data), digest+path.Ext(filename), category)
+	return assetURL, digest, err
+}
+
+// DirStorage 写入本地目录（或挂载的 NAS）的 StorageBackend：文件保存为 Dir/category/filename，返回 BaseURL/category/filename
+type DirStorage struct {
+	Dir     string
+	BaseURL string // 对外访问 Dir 的地址，如 https://static.example.com/drama
+}
+
+func (s *DirStorage) Upload(file io.Reader, filename string, category string) (string, error) {
+	name := filepath.Base(filename)
+	dir := filepath.Join(s.Dir, category)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, file); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(s.BaseURL, "/") + "/" + category + "/" + name, nil
+}
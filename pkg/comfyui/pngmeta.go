@@ -0,0 +1,83 @@
+package comfyui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// ParamsPNGKeyword 生成参数写入 PNG iTXt 块时使用的关键字
+const ParamsPNGKeyword = "generation_params"
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ErrNotPNG 数据不是合法的 PNG
+var ErrNotPNG = errors.New("comfyui image is not a png")
+
+// EmbedPNGText 在 IHDR 之后插入一个未压缩的 iTXt 文本块
+func EmbedPNGText(data []byte, keyword, text string) ([]byte, error) {
+	// iTXt：关键字\0 压缩标记(0) 压缩方法(0) 语言标签\0 翻译关键字\0 文本
+	var payload bytes.Buffer
+	payload.WriteString(keyword)
+	payload.Write([]byte{0, 0, 0, 0, 0})
+	payload.WriteString(text)
+	return insertPNGChunk(data, "iTXt", payload.Bytes())
+}
+
+// insertPNGChunk 在 IHDR 之后插入一个 typ 类型的块
+func insertPNGChunk(data []byte, typ string, payload []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) || len(data) < len(pngSignature)+8 {
+		return nil, ErrNotPNG
+	}
+	// IHDR 固定为第一个块：4 字节长度 + 4 字节类型 + 数据 + 4 字节 CRC
+	ihdrLen := int(binary.BigEndian.Uint32(data[8:12]))
+	ihdrEnd := len(pngSignature) + 12 + ihdrLen
+	if string(data[12:16]) != "IHDR" || ihdrEnd > len(data) {
+		return nil, ErrNotPNG
+	}
+
+	var chunk bytes.Buffer
+	_ = binary.Write(&chunk, binary.BigEndian, uint32(len(payload)))
+	chunk.WriteString(typ)
+	chunk.Write(payload)
+	_ = binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+
+	out := make([]byte, 0, len(data)+chunk.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunk.Bytes()...)
+	out = append(out, data[ihdrEnd:]...)
+	return out, nil
+}
+
+// ReadPNGText 读取 EmbedPNGText 写入的未压缩 iTXt 文本，找不到返回 false
+func ReadPNGText(data []byte, keyword string) (string, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return "", false
+	}
+	for pos := len(pngSignature); pos+12 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		typ := string(data[pos+4 : pos+8])
+		if pos+12+n > len(data) {
+			return "", false
+		}
+		body := data[pos+8 : pos+8+n]
+		if typ == "iTXt" {
+			if parts := bytes.SplitN(body, []byte{0}, 2); len(parts) == 2 && string(parts[0]) == keyword {
+				rest := parts[1]
+				// 跳过压缩标记、压缩方法，再跳过语言标签与翻译关键字
+				if len(rest) >= 2 && rest[0] == 0 {
+					fields := bytes.SplitN(rest[2:], []byte{0}, 3)
+					if len(fields) == 3 {
+						return string(fields[2]), true
+					}
+				}
+			}
+		}
+		if typ == "IEND" {
+			break
+		}
+		pos += 12 + n
+	}
+	return "", false
+}
@@ -0,0 +1,29 @@
+package comfyui
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestEmbedPNGText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	out, err := EmbedPNGText(buf.Bytes(), ParamsPNGKeyword, `{"prompt":"古风庭院"}`)
+	if err != nil {
+		t.Fatalf("EmbedPNGText: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("embedded png no longer decodes: %v", err)
+	}
+	text, ok := ReadPNGText(out, ParamsPNGKeyword)
+	if !ok || text != `{"prompt":"古风庭院"}` {
+		t.Errorf("ReadPNGText = %q, %v", text, ok)
+	}
+	if _, err := EmbedPNGText([]byte("not a png"), ParamsPNGKeyword, "x"); err != ErrNotPNG {
+		t.Errorf("err = %v, want ErrNotPNG", err)
+	}
+}
@@ -0,0 +1,223 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// defaultPoolHealthInterval Pool 重新检查实例健康状态与队列深度的默认间隔
+const defaultPoolHealthInterval = 15 * time.Second
+
+// ErrNoHealthyBackend Pool 中所有实例都不健康，或都已在本次生成中失败过
+var ErrNoHealthyBackend = errors.New("comfyui pool has no healthy backend")
+
+// Pool 多个 ComfyUI 实例组成的池：定期通过 /system_stats 检查健康、通过 /queue 读取队列深度，
+// 每次生成提交到未满载的健康实例中负载（队列深度 + 本池在途数）最低的一个；实例出错时标记为不健康并换下一个实例重试
+type Pool struct {
+	// MaxConcurrentPerBackend 每个实例同时在途的生成数上限，所有实例都满载时 Generate 等待空位；<=0 表示不限制
+	MaxConcurrentPerBackend int
+	// HealthCheckInterval 健康状态与队列深度的有效期，默认 15s，过期后下一次选择实例前重新检查
+	HealthCheckInterval time.Duration
+
+	backends []*poolBackend
+
+	mu        sync.Mutex
+	released  chan struct{} // 有实例释放空位时关闭并替换，唤醒等待中的 Generate
+	checkedAt time.Time
+	checkMu   sync.Mutex
+}
+
+type poolBackend struct {
+	client     *Client
+	inflight   int
+	queueDepth int
+	healthy    bool
+}
+
+// NewPool 为每个 baseURL 创建 Client（opts 应用到所有实例），maxConcurrentPerBackend 见 Pool.MaxConcurrentPerBackend
+func NewPool(baseURLs []string, maxConcurrentPerBackend int, opts ...Option) *Pool {
+	backends := make([]*poolBackend, len(baseURLs))
+	for i, baseURL := range baseURLs {
+		backends[i] = &poolBackend{client: NewClient(baseURL, opts...)}
+	}
+	return &Pool{MaxConcurrentPerBackend: maxConcurrentPerBackend, backends: backends, released: make(chan struct{})}
+}
+
+// Generate 在选出的实例上生成；连接错误、5xx / 429、等待超时等实例层面的错误会换一个实例重试（超时先取消原实例上的任务），
+// 400 提交错误、工作流执行错误、ctx 取消等与实例无关的错误直接返回。预算与配额只在第一次成功通过检查时扣减，重试不重复扣
+func (pl *Pool) Generate(ctx context.Context, p *Params) (*GenerateResult, error) {
+	if len(pl.backends) == 0 {
+		return nil, fmt.Errorf("comfyui pool has no backends")
+	}
+	ctx = withChargeOnce(ctx)
+	tried := make(map[*poolBackend]bool, len(pl.backends))
+	var lastErr error
+	for len(tried) < len(pl.backends) {
+		b, err := pl.acquire(ctx, tried)
+		if err != nil {
+			if lastErr != nil && errors.Is(err, ErrNoHealthyBackend) {
+				return nil, lastErr
+			}
+			return nil, err
+		}
+		tried[b] = true
+		// Generate 会填充默认值、随机种子，每个实例使用调用方参数的副本
+		cp := *p
+		result, err := b.client.Generate(ctx, &cp)
+		pl.release(b)
+		if err == nil || !isBackendError(ctx, err) {
+			return result, err
+		}
+		b.client.warnw("ComfyUI backend failed, trying another backend", "base_url", b.client.BaseURL, "error", err)
+		cancelTimedOut(ctx, b.client, err)
+		pl.markUnhealthy(b)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// isBackendError 错误是否由实例本身引起（换实例可能成功）：连接错误、超时、429 与 5xx；
+// 400 等提交错误说明工作流本身无效，换实例同样失败
+func isBackendError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var submitErr *SubmitError
+	if errors.As(err, &submitErr) {
+		return submitErr.StatusCode >= 500 || submitErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.Is(err, ErrPollingTimeout) || errors.Is(err, ErrRateLimited) || errors.As(err, &urlErr)
+}
+
+// cancelTimedOut 等待超时后取消原实例上的任务，避免换实例重试时同一任务在两台机器上重复占用 GPU
+func cancelTimedOut(ctx context.Context, c *Client, err error) {
+	var timeoutErr *PollingTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+	if err := c.Cancel(ctx, timeoutErr.PromptID); err != nil {
+		c.warnw("ComfyUI cancel timed out prompt failed", "base_url", c.BaseURL, "prompt_id", timeoutErr.PromptID, "error", err)
+	}
+}
+
+// acquire 选出负载最低的可用实例并占用一个空位；健康实例都满载时等待空位释放
+func (pl *Pool) acquire(ctx context.Context, tried map[*poolBackend]bool) (*poolBackend, error) {
+	pl.refreshHealth(ctx)
+	for {
+		pl.mu.Lock()
+		var best *poolBackend
+		candidates := 0
+		for _, b := range pl.backends {
+			if !b.healthy || tried[b] {
+				continue
+			}
+			candidates++
+			if pl.MaxConcurrentPerBackend > 0 && b.inflight >= pl.MaxConcurrentPerBackend {
+				continue
+			}
+			if best == nil || b.queueDepth+b.inflight < best.queueDepth+best.inflight {
+				best = b
+			}
+		}
+		if best != nil {
+			best.inflight++
+			pl.mu.Unlock()
+			return best, nil
+		}
+		released := pl.released
+		pl.mu.Unlock()
+		if candidates == 0 {
+			return nil, ErrNoHealthyBackend
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (pl *Pool) release(b *poolBackend) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	b.inflight--
+	close(pl.released)
+	pl.released = make(chan struct{})
+}
+
+// markUnhealthy 实例出错后在下一次健康检查之前不再分配任务
+func (pl *Pool) markUnhealthy(b *poolBackend) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	b.healthy = false
+	close(pl.released)
+	pl.released = make(chan struct{})
+}
+
+// refreshHealth 健康信息过期时并发检查所有实例；同一时刻只有一个调用方执行检查，其余直接使用旧数据
+func (pl *Pool) refreshHealth(ctx context.Context) {
+	interval := pl.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultPoolHealthInterval
+	}
+	pl.checkMu.Lock()
+	defer pl.checkMu.Unlock()
+	pl.mu.Lock()
+	fresh := !pl.checkedAt.IsZero() && time.Since(pl.checkedAt) < interval
+	pl.mu.Unlock()
+	if fresh {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queueProbeTimeout)
+	defer cancel()
+	type probe struct {
+		healthy bool
+		depth   int
+	}
+	probes := make([]probe, len(pl.backends))
+	var wg sync.WaitGroup
+	for i, b := range pl.backends {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			if err := c.HealthCheck(ctx); err != nil {
+				return
+			}
+			q, err := c.QueueStatus(ctx)
+			if err != nil {
+				return
+			}
+			probes[i] = probe{healthy: true, depth: q.RunningCount + q.PendingCount}
+		}(i, b.client)
+	}
+	wg.Wait()
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	for i, b := range pl.backends {
+		// 队列深度包含本池已提交的任务，减去在途数避免重复计算
+		b.healthy = probes[i].healthy
+		b.queueDepth = max(0, probes[i].depth-b.inflight)
+	}
+	pl.checkedAt = time.Now()
+	close(pl.released)
+	pl.released = make(chan struct{})
+}
+
+// Backends 返回池中的实例，便于单独调用 QueueStatus 等接口
+func (pl *Pool) Backends() []*Client {
+	clients := make([]*Client, len(pl.backends))
+	for i, b := range pl.backends {
+		clients[i] = b.client
+	}
+	return clients
+}
@@ -0,0 +1,209 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// uploadFromURL 下载 ComfyUI 上的图片并上传到 input 目录，返回 LoadImage 可用的文件名；
+// imageURL 须为 BaseURL 所在主机的地址（否则返回 ErrHostMismatch），大小受 MaxImageBytes 限制
+func (c *Client) uploadFromURL(ctx context.Context, imageURL string) (string, error) {
+	data, err := c.fetch(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	return c.UploadImage(ctx, data, "drama_"+path.Base(cleanFilename(imageURL)))
+}
+
+// cleanFilename 从图片地址中取 filename 参数（/view?filename=...），取不到则返回 input.png
+func cleanFilename(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if name := u.Query().Get("filename"); name != "" {
+			return name
+		}
+	}
+	return "input.png"
+}
+
+// runImageWorkflow 提交后处理工作流并等待输出图片
+func (c *Client) runImageWorkflow(ctx context.Context, workflow map[string]interface{}) (string, error) {
+	return c.execute(ctx, workflow, nil)
+}
+
+// ApplyTextOverlay 在图片上叠加标题文字（如剧集名），使用 Comfyroll 的 "CR Overlay Text" 节点；
+// fontStyle 为 ComfyUI fonts 目录下的字体文件名，为空时使用 Roboto-Regular.ttf
+func (c *Client) ApplyTextOverlay(ctx context.Context, imageURL, text, fontStyle string) (string, error) {
+	name, err := c.uploadFromURL(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	if fontStyle == "" {
+		fontStyle = "Roboto-Regular.ttf"
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	overlay := b.AddNode("CR Overlay Text", map[string]interface{}{
+		"image":            b.Out(load, 0),
+		"text":             text,
+		"font_name":        fontStyle,
+		"font_size":        72,
+		"font_color":       "white",
+		"align":            "center",
+		"justify":          "center",
+		"margins":          0,
+		"line_spacing":     0,
+		"position_x":       0,
+		"position_y":       -400,
+		"rotation_angle":   0,
+		"rotation_options": "text center",
+	})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_title", "images": b.Out(overlay, 0)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// ApplyColorGrade 使用 ComfyUI_essentials 的 "ImageApplyLUT+" 节点套用 .cube LUT 调色；
+// lutFilePath 为 ComfyUI luts 目录下的相对路径，strength 为与原图的混合比例（0~1）
+func (c *Client) ApplyColorGrade(ctx context.Context, imageURL, lutFilePath string, strength float64) (string, error) {
+	if strength < 0 || strength > 1 {
+		return "", fmt.Errorf("comfyui lut strength must be within [0, 1], got %v", strength)
+	}
+	name, err := c.uploadFromURL(ctx, imageURL)
+	if err != nil {
+		return "", err
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	graded := b.AddNode("ImageApplyLUT+", map[string]interface{}{
+		"image":            b.Out(load, 0),
+		"lut_file":         lutFilePath,
+		"gamma_correction": true,
+		"clip_values":      true,
+		"strength":         strength,
+	})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_graded", "images": b.Out(graded, 0)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// NoOpWorkflow 上传本地图片并原样保存（LoadImage → SaveImage），不做任何模型推理；
+// 用于集成测试验证上传 / 下载链路与图片格式兼容性，不消耗 GPU
+func (c *Client) NoOpWorkflow(ctx context.Context, imagePath string) (string, error) {
+	name, err := c.uploadFile(ctx, imagePath)
+	if err != nil {
+		return "", err
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_noop", "images": b.Out(load, 0)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// CropImage 上传本地图片并裁剪出左上角为 (x, y)、大小为 width × height 的区域（LoadImage → ImageCrop → SaveImage），
+// 返回裁剪结果的 URL；超出原图的部分由 ImageCrop 截断
+func (c *Client) CropImage(ctx context.Context, imagePath string, x, y, width, height int) (string, error) {
+	if x < 0 || y < 0 || width <= 0 || height <= 0 {
+		return "", fmt.Errorf("comfyui invalid crop box (%d, %d, %d, %d)", x, y, width, height)
+	}
+	name, err := c.uploadFile(ctx, imagePath)
+	if err != nil {
+		return "", err
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	crop := b.AddNode("ImageCrop", map[string]interface{}{
+		"image":  b.Out(load, 0),
+		"width":  width,
+		"height": height,
+		"x":      x,
+		"y":      y,
+	})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_crop", "images": b.Out(crop, 0)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// Letterbox 上传本地图片，按比例缩放到能放进 targetWidth × targetHeight 的最大尺寸后居中，
+// 四周用黑边补齐（LoadImage → ImageScale → ImagePad+ → SaveImage，ImagePad+ 来自 ComfyUI_essentials），返回结果 URL
+func (c *Client) Letterbox(ctx context.Context, imagePath string, targetWidth, targetHeight int) (string, error) {
+	if targetWidth <= 0 || targetHeight <= 0 {
+		return "", fmt.Errorf("comfyui invalid letterbox size %dx%d", targetWidth, targetHeight)
+	}
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("comfyui read image: %w", err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("comfyui decode image: %w", err)
+	}
+	name, err := c.UploadImage(ctx, data, filepath.Base(imagePath))
+	if err != nil {
+		return "", err
+	}
+
+	// 宽、高各自的缩放比取较小者，保证整张图都在目标框内
+	width, height := targetWidth, cfg.Height*targetWidth/cfg.Width
+	if height > targetHeight {
+		width, height = cfg.Width*targetHeight/cfg.Height, targetHeight
+	}
+	b := NewWorkflowBuilder()
+	img := b.Out(b.AddNode("LoadImage", map[string]interface{}{"image": name}), 0)
+	if width != cfg.Width || height != cfg.Height {
+		img = b.Out(b.AddNode("ImageScale", map[string]interface{}{
+			"image":          img,
+			"upscale_method": "lanczos",
+			"width":          width,
+			"height":         height,
+			"crop":           "disabled",
+		}), 0)
+	}
+	left, top := (targetWidth-width)/2, (targetHeight-height)/2
+	pad := b.AddNode("ImagePad+", map[string]interface{}{
+		"image":         img,
+		"left":          left,
+		"right":         targetWidth - width - left,
+		"top":           top,
+		"bottom":        targetHeight - height - top,
+		"extra_padding": 0,
+		"pad_mode":      "color",
+		"color":         "#000000",
+	})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_letterbox", "images": b.Out(pad, 0)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// SegmentImage 上传本地图片，用 CLIPSeg（ComfyUI-CLIPSeg）按文字描述 targetObject 分割出目标，
+// 返回二值蒙版 PNG 的 URL（目标为白色）：LoadImage → CLIPSeg → SaveImage，保存 CLIPSeg 第 3 个输出 "BW Mask"
+func (c *Client) SegmentImage(ctx context.Context, imagePath string, targetObject string) (string, error) {
+	if targetObject == "" {
+		return "", fmt.Errorf("comfyui segment target object is required")
+	}
+	name, err := c.uploadFile(ctx, imagePath)
+	if err != nil {
+		return "", err
+	}
+	b := NewWorkflowBuilder()
+	load := b.AddNode("LoadImage", map[string]interface{}{"image": name})
+	seg := b.AddNode("CLIPSeg", map[string]interface{}{
+		"image":           b.Out(load, 0),
+		"text":            targetObject,
+		"blur":            7,
+		"threshold":       0.4,
+		"dilation_factor": 4,
+	})
+	b.AddNode("SaveImage", map[string]interface{}{"filename_prefix": "drama_mask", "images": b.Out(seg, 2)})
+	return c.runImageWorkflow(ctx, b.Build())
+}
+
+// uploadFile 读取本地图片并上传到 input 目录，返回 LoadImage 可用的文件名
+func (c *Client) uploadFile(ctx context.Context, imagePath string) (string, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("comfyui read image: %w", err)
+	}
+	return c.UploadImage(ctx, data, filepath.Base(imagePath))
+}
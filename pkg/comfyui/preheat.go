@@ -0,0 +1,99 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// preheatRetryDelay 后台补充失败后的重试间隔
+const preheatRetryDelay = 5 * time.Second
+
+// PreheatPool 预先生成好的图片池，见 Preheat
+type PreheatPool struct {
+	c      *Client
+	params Params
+	urls   chan string
+	refill chan struct{}
+	cancel context.CancelFunc
+}
+
+// Preheat 立即按 p 同步生成 poolSize 张图片放入池中，之后每 Pop 一张由后台 goroutine 补充一张，ctx 结束或 Close 后停止补充；
+// p 使用固定种子时每张图相同，通常应设置 SeedModeRandom
+func (c *Client) Preheat(ctx context.Context, p *Params, poolSize int) (*PreheatPool, error) {
+	if poolSize <= 0 {
+		return nil, fmt.Errorf("comfyui preheat pool size must be positive, got %d", poolSize)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	pool := &PreheatPool{
+		c:      c,
+		params: *p,
+		urls:   make(chan string, poolSize),
+		refill: make(chan struct{}, poolSize),
+		cancel: cancel,
+	}
+	for i := 0; i < poolSize; i++ {
+		imageURL, err := pool.generate(ctx)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("comfyui preheat: %w", err)
+		}
+		pool.urls <- imageURL
+	}
+	go pool.replenish(ctx)
+	return pool, nil
+}
+
+// Pop 取出一张预生成的图片，池为空时阻塞到补充完成或 ctx 结束
+func (pp *PreheatPool) Pop(ctx context.Context) (string, error) {
+	select {
+	case imageURL := <-pp.urls:
+		// Close 之后 replenish 已退出，不阻塞
+		select {
+		case pp.refill <- struct{}{}:
+		default:
+		}
+		return imageURL, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Close 停止后台补充，已在池中的图片仍可 Pop
+func (pp *PreheatPool) Close() {
+	pp.cancel()
+}
+
+func (pp *PreheatPool) replenish(ctx context.Context) {
+	for {
+		select {
+		case <-pp.refill:
+		case <-ctx.Done():
+			return
+		}
+		for {
+			imageURL, err := pp.generate(ctx)
+			if err == nil {
+				pp.urls <- imageURL
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			pp.c.warnw("ComfyUI preheat replenish failed", "error", err)
+			if sleepCtx(ctx, preheatRetryDelay) != nil {
+				return
+			}
+		}
+	}
+}
+
+// generate 每次复制一份参数，避免默认值与随机种子写回共享的 params
+func (pp *PreheatPool) generate(ctx context.Context) (string, error) {
+	p := pp.params
+	res, err := pp.c.Generate(ctx, &p)
+	if err != nil {
+		return "", err
+	}
+	return res.ImageURL, nil
+}
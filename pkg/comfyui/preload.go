@@ -0,0 +1,68 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+)
+
+// PreloadModels 提交一个只加载指定模型的极小工作流（64×64、1 步、PreviewImage 临时输出），
+// 让 ComfyUI 提前把模型读入显存，用于服务部署后的冷启动预热。参数为空时使用 flux.json 默认模型；
+// clipName 对应 DualCLIPLoader 的 clip_name1（T5），clip_name2 固定为 clip_l
+func (c *Client) PreloadModels(ctx context.Context, unetName, clipName, vaeName string) error {
+	if unetName == "" {
+		unetName = DefaultUNETModel
+	}
+	if clipName == "" {
+		clipName = DefaultT5Model
+	}
+	if vaeName == "" {
+		vaeName = DefaultVAEModel
+	}
+	// ComfyUI 不执行没有输出节点的 prompt，因此用 PreviewImage 代替 SaveImage，结果只落在 temp 目录
+	workflow := map[string]interface{}{
+		"1": map[string]interface{}{
+			"inputs":     map[string]interface{}{"unet_name": unetName, "weight_dtype": "fp8_e4m3fn"},
+			"class_type": "UNETLoader",
+		},
+		"2": map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"clip_name1": clipName, "clip_name2": DefaultCLIPLModel,
+				"type": "flux", "device": "default",
+			},
+			"class_type": "DualCLIPLoader",
+		},
+		"3": map[string]interface{}{
+			"inputs":     map[string]interface{}{"vae_name": vaeName},
+			"class_type": "VAELoader",
+		},
+		"4": map[string]interface{}{
+			"inputs":     map[string]interface{}{"text": "", "clip": []interface{}{"2", 0}},
+			"class_type": "CLIPTextEncode",
+		},
+		"5": map[string]interface{}{
+			"inputs":     map[string]interface{}{"width": 64, "height": 64, "batch_size": 1},
+			"class_type": "EmptyLatentImage",
+		},
+		"6": map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"seed": 0, "steps": 1, "cfg": 1,
+				"sampler_name": "euler", "scheduler": "simple", "denoise": 1,
+				"model": []interface{}{"1", 0}, "positive": []interface{}{"4", 0},
+				"negative": []interface{}{"4", 0}, "latent_image": []interface{}{"5", 0},
+			},
+			"class_type": "KSampler",
+		},
+		"7": map[string]interface{}{
+			"inputs":     map[string]interface{}{"samples": []interface{}{"6", 0}, "vae": []interface{}{"3", 0}},
+			"class_type": "VAEDecode",
+		},
+		"8": map[string]interface{}{
+			"inputs":     map[string]interface{}{"images": []interface{}{"7", 0}},
+			"class_type": "PreviewImage",
+		},
+	}
+	if _, err := c.execute(ctx, workflow, nil); err != nil {
+		return fmt.Errorf("comfyui preload models: %w", err)
+	}
+	return nil
+}
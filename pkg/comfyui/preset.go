@@ -0,0 +1,192 @@
+package comfyui
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoadWorkflowPresets 读取目录下所有 API 格式的工作流 JSON（ComfyUI "Save (API Format)" 导出），以文件名（不含扩展名）为预设名
+func LoadWorkflowPresets(dir string) (map[string]map[string]interface{}, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	presets := make(map[string]map[string]interface{}, len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var wf map[string]interface{}
+		if err := json.Unmarshal(data, &wf); err != nil {
+			return nil, fmt.Errorf("comfyui parse preset %s: %w", filepath.Base(f), err)
+		}
+		presets[strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))] = wf
+	}
+	return presets, nil
+}
+
+// presetCache DynamicPresets 模式下缓存上次加载的预设，目录内文件及修改时间都未变化时直接复用
+type presetCache struct {
+	mu      sync.Mutex
+	mtimes  map[string]time.Time
+	presets map[string]map[string]interface{}
+}
+
+func (pc *presetCache) load(dir string) (map[string]map[string]interface{}, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	mtimes := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		mtimes[f] = info.ModTime()
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.presets != nil && sameMTimes(pc.mtimes, mtimes) {
+		return pc.presets, nil
+	}
+	presets, err := LoadWorkflowPresets(dir)
+	if err != nil {
+		return nil, err
+	}
+	pc.mtimes, pc.presets = mtimes, presets
+	return presets, nil
+}
+
+func sameMTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for f, t := range a {
+		if !b[f].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkflowChecksum 工作流的 SHA-256（encoding/json 对 map 键排序，结果与节点插入顺序无关）
+func WorkflowChecksum(wf map[string]interface{}) (string, error) {
+	data, err := json.Marshal(wf)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// GenerateFromPreset 提交 Client.Presets 中的工作流并返回输出图片 URL（DynamicPresets 时每次从 PresetsDir 重新读取）；
+// 配置了 FingerprintStore 时会比对上次运行的校验和，预设被改动时记录警告
+func (c *Client) GenerateFromPreset(ctx context.Context, name string) (string, error) {
+	presets := c.Presets
+	if c.DynamicPresets {
+		var err error
+		if presets, err = c.presetCache.load(c.PresetsDir); err != nil {
+			return "", err
+		}
+	}
+	wf, ok := presets[name]
+	if !ok {
+		return "", fmt.Errorf("comfyui unknown workflow preset %q", name)
+	}
+	if c.FingerprintStore != nil {
+		if err := c.checkFingerprint(name, wf); err != nil {
+			return "", err
+		}
+	}
+	return c.runImageWorkflow(ctx, wf)
+}
+
+func (c *Client) checkFingerprint(name string, wf map[string]interface{}) error {
+	hash, err := WorkflowChecksum(wf)
+	if err != nil {
+		return err
+	}
+	prev, found, err := c.FingerprintStore.Load(name)
+	if err != nil {
+		return fmt.Errorf("comfyui load workflow fingerprint: %w", err)
+	}
+	if found && prev == hash {
+		return nil
+	}
+	if found {
+		c.warnw("ComfyUI workflow preset changed since last run", "preset", name, "old_hash", prev, "new_hash", hash)
+	}
+	if err := c.FingerprintStore.Store(name, hash); err != nil {
+		return fmt.Errorf("comfyui store workflow fingerprint: %w", err)
+	}
+	return nil
+}
+
+// WorkflowFingerprintStore 保存各预设最近一次运行时的工作流校验和
+type WorkflowFingerprintStore interface {
+	Store(name, hash string) error
+	Load(name string) (string, bool, error)
+}
+
+// JSONFileFingerprintStore 以单个 JSON 文件（预设名 -> 校验和）保存指纹
+type JSONFileFingerprintStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (s *JSONFileFingerprintStore) Load(name string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return "", false, err
+	}
+	hash, ok := all[name]
+	return hash, ok, nil
+}
+
+func (s *JSONFileFingerprintStore) Store(name, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	all[name] = hash
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免写到一半进程退出导致文件损坏
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+func (s *JSONFileFingerprintStore) read() (map[string]string, error) {
+	all := map[string]string{}
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("comfyui parse fingerprint file %s: %w", s.Path, err)
+	}
+	return all, nil
+}
@@ -0,0 +1,31 @@
+package comfyui
+
+import "context"
+
+// previewScale 预览图相对完整尺寸的缩小倍数
+const previewScale = 4
+
+// GeneratePreviewFirst 先以 1/4 分辨率快速生成预览并回调 previewCallback（可为 nil），再以完整分辨率生成并返回其 URL；
+// 两次使用同一个种子（随机种子模式下先确定种子），最终图与预览构图接近。p 不会被修改
+func (c *Client) GeneratePreviewFirst(ctx context.Context, p *Params, previewCallback func(previewURL string)) (string, error) {
+	full := *p
+	applyDefaults(&full)
+	full.SeedMode = SeedModeFixed
+
+	preview := full
+	preview.Width = previewDimension(full.Width)
+	preview.Height = previewDimension(full.Height)
+	previewURL, err := c.GenerateWithProgress(ctx, &preview, nil)
+	if err != nil {
+		return "", err
+	}
+	if previewCallback != nil {
+		previewCallback(previewURL)
+	}
+	return c.GenerateWithProgress(ctx, &full, nil)
+}
+
+// previewDimension 缩小到 1/4 并向下取整到 8 的倍数（潜空间按 8 倍下采样），最小 64
+func previewDimension(size int) int {
+	return max(64, size/previewScale/8*8)
+}
@@ -0,0 +1,42 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"time"
+)
+
+// ProbeResult ProbeModel 的结果
+type ProbeResult struct {
+	Success          bool // 生成成功且输出不是空白图
+	GenerationTimeMs int64
+	OutputURL        string
+}
+
+// ProbeModel 用极低成本（64×64、3 步）跑一次生成，验证新部署的模型能正常出图；
+// 提交或轮询失败时返回 error，出图但无法解码或为空白图时 Success 为 false
+func (c *Client) ProbeModel(ctx context.Context, modelName string, referencePrompt string) (*ProbeResult, error) {
+	p := &Params{
+		Prompt:        referencePrompt,
+		Width:         64,
+		Height:        64,
+		SamplerConfig: SamplerConfig{Steps: 3},
+		UNETModelName: modelName,
+	}
+	start := time.Now()
+	imageURL, err := c.generate(ctx, p, nil, nil)
+	result := &ProbeResult{GenerationTimeMs: time.Since(start).Milliseconds(), OutputURL: imageURL}
+	if err != nil {
+		return result, err
+	}
+	data, err := c.fetch(ctx, imageURL)
+	if err != nil {
+		return result, err
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return result, nil
+	}
+	result.Success = !isBlankImage(data, c.blankThreshold())
+	return result, nil
+}
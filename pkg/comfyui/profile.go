@@ -0,0 +1,57 @@
+package comfyui
+
+import "encoding/json"
+
+// WorkflowProfile Params 各字段对序列化后工作流 JSON 大小的贡献（字节），
+// 用于排查哪些提示词特性推高了提交 /prompt 的流量
+type WorkflowProfile struct {
+	TotalBytes int `json:"total_bytes"`
+	// BaseBytes 去掉下列可选特性后的基础工作流大小
+	BaseBytes                int `json:"base_bytes"`
+	PromptBytes              int `json:"prompt_bytes"`
+	LoRAsBytes               int `json:"loras_bytes"`
+	AdvancedSamplerBytes     int `json:"advanced_sampler_bytes"`
+	UNETShardsBytes          int `json:"unet_shards_bytes"`
+	SafetyCheckBytes         int `json:"safety_check_bytes"`
+	MetadataBytes            int `json:"metadata_bytes"`
+	TranslateCredentialBytes int `json:"translate_credential_bytes"`
+}
+
+// ProfileWorkflow 逐个去掉 Params 的可选字段重新构建工作流，与完整工作流的大小差即该字段的贡献；
+// 不包含 trace_id 等提交时才注入的节点。p 不会被修改
+func ProfileWorkflow(p *Params) *WorkflowProfile {
+	base := *p
+	applyDefaults(&base)
+	// 固定随机出的种子，避免每次构建的种子位数不同影响差值
+	base.SeedMode = SeedModeFixed
+	size := func(mutate func(p *Params)) int {
+		cp := base
+		if mutate != nil {
+			mutate(&cp)
+		}
+		data, _ := json.Marshal((&Client{}).buildWorkflow(&cp))
+		return len(data)
+	}
+	total := size(nil)
+	contribution := func(mutate func(p *Params)) int {
+		return total - size(mutate)
+	}
+
+	profile := &WorkflowProfile{
+		TotalBytes:           total,
+		PromptBytes:          contribution(func(p *Params) { p.Prompt = "" }),
+		LoRAsBytes:           contribution(func(p *Params) { p.LoRAs = nil }),
+		AdvancedSamplerBytes: contribution(func(p *Params) { p.AdvancedSampler = nil }),
+		UNETShardsBytes:      contribution(func(p *Params) { p.UNETShards = nil }),
+		SafetyCheckBytes:     contribution(func(p *Params) { p.SafetyCheck = false }),
+		MetadataBytes:        contribution(func(p *Params) { p.Metadata = nil }),
+		TranslateCredentialBytes: contribution(func(p *Params) {
+			p.BaiduTranslateAppID, p.BaiduTranslateAppKey = "", ""
+		}),
+	}
+	profile.BaseBytes = size(func(p *Params) {
+		p.Prompt, p.LoRAs, p.AdvancedSampler, p.UNETShards, p.SafetyCheck, p.Metadata = "", nil, nil, nil, false, nil
+		p.BaiduTranslateAppID, p.BaiduTranslateAppKey = "", ""
+	})
+	return profile
+}
@@ -0,0 +1,53 @@
+package comfyui
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// progressTotal 估算进度时使用的总步数（即百分比）
+	progressTotal = 100
+	// defaultGenerationDuration 还没有历史记录时假定的生成耗时（Flux 1920×1080 / 25 步）
+	defaultGenerationDuration = 30 * time.Second
+	durationHistorySize       = 50
+)
+
+// durationHistory 记录最近若干次生成的耗时，用于估算轮询进度
+type durationHistory struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (h *durationHistory) record(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples = append(h.samples, d)
+	if len(h.samples) > durationHistorySize {
+		h.samples = h.samples[len(h.samples)-durationHistorySize:]
+	}
+}
+
+func (h *durationHistory) median() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) == 0 {
+		return defaultGenerationDuration
+	}
+	sorted := append([]time.Duration(nil), h.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// reportEstimatedProgress 按已耗时 / 历史中位数估算进度，完成前最多报告到 99%
+func (c *Client) reportEstimatedProgress(elapsed, median time.Duration) {
+	if c.OnProgress == nil || median <= 0 {
+		return
+	}
+	step := int(float64(elapsed) / float64(median) * progressTotal)
+	if step > progressTotal-1 {
+		step = progressTotal - 1
+	}
+	c.OnProgress(step, progressTotal)
+}
@@ -0,0 +1,49 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrProjectQuotaExceeded 项目当天的生成次数已达 Client.ProjectQuota 上限
+var ErrProjectQuotaExceeded = errors.New("comfyui project daily quota exceeded")
+
+// projectCounter 按自然日（本地时间）统计各项目的生成次数
+type projectCounter struct {
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+}
+
+// take 当天次数未达 limit 时计一次
+func (pc *projectCounter) take(projectID string, limit int) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if today := time.Now().Format("2006-01-02"); pc.day != today {
+		pc.day, pc.counts = today, map[string]int{}
+	}
+	if pc.counts[projectID] >= limit {
+		return fmt.Errorf("%w: project %s, limit %d", ErrProjectQuotaExceeded, projectID, limit)
+	}
+	pc.counts[projectID]++
+	return nil
+}
+
+// checkProjectQuota Params.Metadata["project_id"] 在 ProjectQuota 中有上限时扣减当天次数
+func (c *Client) checkProjectQuota(p *Params) error {
+	projectID := p.Metadata["project_id"]
+	limit, ok := c.ProjectQuota[projectID]
+	if projectID == "" || !ok {
+		return nil
+	}
+	return c.projects.take(projectID, limit)
+}
+
+// ResetProjectQuotas 清零项目当天已用次数，用于人工放行
+func (c *Client) ResetProjectQuotas(projectID string) {
+	c.projects.mu.Lock()
+	defer c.projects.mu.Unlock()
+	delete(c.projects.counts, projectID)
+}
@@ -0,0 +1,117 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// QueueInfo /queue 返回的队列概况
+type QueueInfo struct {
+	RunningCount int
+	PendingCount int
+}
+
+// QueueStatus 查询 GET /queue，返回正在执行与排队中的任务数
+func (c *Client) QueueStatus(ctx context.Context) (*QueueInfo, error) {
+	running, pending, err := c.fetchQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueInfo{RunningCount: len(running), PendingCount: len(pending)}, nil
+}
+
+// QueuePosition 返回 promptID 前面还有多少个任务（含正在执行的），running 表示该任务正在执行（此时 pos 为 0）；
+// 排队中且前面没有任务时 pos 同样为 0，需以 running 区分。不在队列中（尚未提交或已完成）时 pos 为 -1；
+// 不使用 WithResponseCache 的缓存，Cancel 与 Status 据此判断的是当前状态
+func (c *Client) QueuePosition(ctx context.Context, promptID string) (pos int, running bool, err error) {
+	runningItems, pending, err := c.fetchQueue(withoutCache(ctx))
+	if err != nil {
+		return 0, false, err
+	}
+	for _, item := range runningItems {
+		if queueItemPromptID(item) == promptID {
+			return 0, true, nil
+		}
+	}
+	for i, item := range pending {
+		if queueItemPromptID(item) == promptID {
+			return len(runningItems) + i, false, nil
+		}
+	}
+	return -1, false, nil
+}
+
+// fetchQueue GET /queue，queue_pending 按执行顺序返回
+func (c *Client) fetchQueue(ctx context.Context) (running, pending []json.RawMessage, err error) {
+	cl, err := c.apiClient(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := cl.GetQueue(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("comfyui queue: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("comfyui queue %s", resp.Status)
+	}
+	var queue api.Queue
+	if err := json.NewDecoder(resp.Body).Decode(&queue); err != nil {
+		return nil, nil, fmt.Errorf("comfyui decode queue: %w", err)
+	}
+	// 服务器按提交序号排序执行，返回的 pending 列表不保证有序
+	sort.SliceStable(queue.QueuePending, func(i, j int) bool {
+		return queueItemNumber(queue.QueuePending[i]) < queueItemNumber(queue.QueuePending[j])
+	})
+	return queue.QueueRunning, queue.QueuePending, nil
+}
+
+// 队列项格式为 [number, prompt_id, prompt, extra_data, outputs_to_execute]
+func queueItemNumber(item json.RawMessage) float64 {
+	var fields []json.RawMessage
+	var number float64
+	if json.Unmarshal(item, &fields) == nil && len(fields) > 0 {
+		_ = json.Unmarshal(fields[0], &number)
+	}
+	return number
+}
+
+func queueItemPromptID(item json.RawMessage) string {
+	var fields []json.RawMessage
+	var promptID string
+	if json.Unmarshal(item, &fields) == nil && len(fields) > 1 {
+		_ = json.Unmarshal(fields[1], &promptID)
+	}
+	return promptID
+}
+
+// ClearQueue 清空 ComfyUI 中所有排队中的任务（POST /queue {"clear": true}），不影响正在执行的任务
+func (c *Client) ClearQueue(ctx context.Context) error {
+	return c.callAPI("/queue", func(cl *api.Client) (*http.Response, error) {
+		return cl.PostQueue(ctx, api.PostQueueJSONRequestBody{Clear: true})
+	})
+}
+
+// callAPI 经生成客户端发送只关心是否成功的请求，path 用于错误信息
+func (c *Client) callAPI(path string, call func(cl *api.Client) (*http.Response, error)) error {
+	cl, err := c.apiClient(false)
+	if err != nil {
+		return err
+	}
+	resp, err := call(cl)
+	if err != nil {
+		return fmt.Errorf("comfyui %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("comfyui %s %s: %s", path, resp.Status, string(b))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQuotaExceeded 租户在当前窗口内的 GPU 配额已用完
+var ErrQuotaExceeded = errors.New("comfyui tenant quota exceeded")
+
+// QuotaManager 多租户 GPU 配额；每次 Generate 提交前按 quotaCost 扣减 Params.TenantID 的额度
+type QuotaManager interface {
+	Deduct(ctx context.Context, tenantID string, cost int) error
+	Remaining(ctx context.Context, tenantID string) (int, error)
+}
+
+func WithQuotaManager(qm QuotaManager) Option {
+	return func(c *Client) {
+		c.quota = qm
+	}
+}
+
+// quotaCostNormalizer 1 单位配额对应的 Steps×Width×Height，即 512×512 跑 20 步
+const quotaCostNormalizer = 512 * 512 * 20
+
+// quotaCost 按 Steps×Width×Height 计算配额，不足 1 单位按 1 计
+func quotaCost(p *Params) int {
+	cost := p.Steps * p.Width * p.Height / quotaCostNormalizer
+	if cost < 1 {
+		return 1
+	}
+	return cost
+}
+
+// quotaWindowScript 滑动窗口：清理窗口外的记录后汇总已用额度，ARGV[3] > 0 时在额度足够的情况下记入本次消耗；
+// 返回扣减后的剩余额度，额度不足时返回 -1。成员格式为 "<cost>:<id>"，score 为 Redis 服务器时间（毫秒）
+const quotaWindowScript = `
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local used = 0
+for _, m in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	used = used + tonumber(string.match(m, '^(%d+):'))
+end
+if cost > 0 then
+	if used + cost > limit then
+		return -1
+	end
+	redis.call('ZADD', KEYS[1], now, cost .. ':' .. ARGV[4])
+	redis.call('PEXPIRE', KEYS[1], window)
+	used = used + cost
+end
+return limit - used
+`
+
+// RedisQuotaManager 基于 Redis 有序集合滑动窗口的配额，每个租户在任意 window 内最多消耗 limit 单位
+type RedisQuotaManager struct {
+	rdb    *redis.Client
+	prefix string
+	limit  int
+	window time.Duration
+	script *redis.Script
+}
+
+// NewRedisQuotaManager 创建配额管理器，租户的记录保存在 "<keyPrefix>:<tenantID>"
+func NewRedisQuotaManager(redisAddr, keyPrefix string, limit int, window time.Duration) QuotaManager {
+	return &RedisQuotaManager{
+		rdb:    redis.NewClient(&redis.Options{Addr: redisAddr}),
+		prefix: keyPrefix,
+		limit:  limit,
+		window: window,
+		script: redis.NewScript(quotaWindowScript),
+	}
+}
+
+func (q *RedisQuotaManager) Deduct(ctx context.Context, tenantID string, cost int) error {
+	remaining, err := q.run(ctx, tenantID, cost)
+	if err != nil {
+		return err
+	}
+	if remaining < 0 {
+		return fmt.Errorf("%w: tenant %s, cost %d", ErrQuotaExceeded, tenantID, cost)
+	}
+	return nil
+}
+
+func (q *RedisQuotaManager) Remaining(ctx context.Context, tenantID string) (int, error) {
+	return q.run(ctx, tenantID, 0)
+}
+
+func (q *RedisQuotaManager) run(ctx context.Context, tenantID string, cost int) (int, error) {
+	n, err := q.script.Run(ctx, q.rdb, []string{q.prefix + ":" + tenantID},
+		q.window.Milliseconds(), q.limit, cost, uuid.NewString()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("comfyui quota: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimiter 限制向 ComfyUI 提交 prompt 的速率；Wait 阻塞到拿到令牌或 ctx 结束
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+func WithRateLimit(r RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = r
+	}
+}
+
+// tokenBucketScript 原子地补充并扣减令牌；返回 0 表示已拿到令牌，否则为需要等待的毫秒数
+// 使用 Redis 服务器时间，避免多实例之间时钟不一致
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`
+
+// RedisRateLimiter 基于 Redis 令牌桶的全局限流，多个服务实例共享同一个 key 即共享配额
+type RedisRateLimiter struct {
+	rdb    *redis.Client
+	key    string
+	rps    float64
+	burst  float64
+	script *redis.Script
+}
+
+// NewRedisRateLimiter 创建每秒最多 rps 次提交的共享限流器，突发上限为 max(1, rps)
+func NewRedisRateLimiter(redisAddr, key string, rps float64) RateLimiter {
+	return &RedisRateLimiter{
+		rdb:    redis.NewClient(&redis.Options{Addr: redisAddr}),
+		key:    key,
+		rps:    rps,
+		burst:  math.Max(1, rps),
+		script: redis.NewScript(tokenBucketScript),
+	}
+}
+
+func (r *RedisRateLimiter) Wait(ctx context.Context) error {
+	if r.rps <= 0 {
+		return nil
+	}
+	for {
+		waitMs, err := r.script.Run(ctx, r.rdb, []string{r.key}, r.rps, r.burst).Int64()
+		if err != nil {
+			return fmt.Errorf("comfyui rate limit: %w", err)
+		}
+		if waitMs <= 0 {
+			return nil
+		}
+		if err := sleepCtx(ctx, time.Duration(waitMs)*time.Millisecond); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	initialReconnectDelay    = 500 * time.Millisecond
+	defaultMaxReconnectDelay = 30 * time.Second
+)
+
+// do 发送请求（未设置 User-Agent 时使用默认值）；开启 AutoReconnect 且连接被拒绝（ComfyUI 重启中）时按指数退避重连，
+// 直到连上或请求的 ctx 结束
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", defaultUserAgent())
+	}
+	c.beginRequest()
+	resp, err := c.httpClient().Do(req)
+	if err == nil || !c.AutoReconnect || !errors.Is(err, syscall.ECONNREFUSED) {
+		c.endRequest(err)
+		return resp, err
+	}
+
+	maxDelay := c.MaxReconnectDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxReconnectDelay
+	}
+	delay := initialReconnectDelay
+	for attempt := 1; ; attempt++ {
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+		c.setState(StateReconnecting)
+		if c.OnReconnecting != nil {
+			c.OnReconnecting(attempt, delay)
+		}
+		c.warnw("ComfyUI connection refused, reconnecting", "attempt", attempt, "delay", delay.String())
+		if err := sleepCtx(req.Context(), delay); err != nil {
+			c.setState(StateDisconnected)
+			return nil, fmt.Errorf("comfyui reconnect: %w", err)
+		}
+
+		retry, cloneErr := cloneRequest(req)
+		if cloneErr != nil {
+			c.setState(StateDisconnected)
+			return nil, fmt.Errorf("comfyui reconnect: %w", cloneErr)
+		}
+		resp, err = c.httpClient().Do(retry)
+		if err == nil {
+			c.setState(StateConnected)
+			if c.OnReconnected != nil {
+				c.OnReconnected(attempt)
+			}
+			return resp, nil
+		}
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			c.setState(StateDisconnected)
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// cloneRequest 复制请求用于重试，有请求体时通过 GetBody 重新获取
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return retry, nil
+}
@@ -0,0 +1,136 @@
+package comfyui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// recordedExchange 录制文件格式：一次请求及其响应
+type recordedExchange struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"` // 路径 + 查询参数，如 /history/xxx
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// RecordingClient 包装 http.RoundTripper，把与 ComfyUI 的每次请求/响应按顺序写入 Dir
+// 用法：client.HTTP = &http.Client{Transport: &comfyui.RecordingClient{Dir: "testdata/session1"}}
+type RecordingClient struct {
+	Dir       string
+	Transport http.RoundTripper // 为空时使用 http.DefaultTransport
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (r *RecordingClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := r.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	ex := recordedExchange{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   string(body),
+	}
+	if err := r.save(&ex); err != nil {
+		return nil, fmt.Errorf("comfyui record: %w", err)
+	}
+	return resp, nil
+}
+
+func (r *RecordingClient) save(ex *recordedExchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return err
+	}
+	r.seq++
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%04d_%s.json", r.seq, strings.ToLower(ex.Method))
+	return os.WriteFile(filepath.Join(r.Dir, name), data, 0644)
+}
+
+// ReplayServer 读取 RecordingClient 录制的目录，启动一个按 method + URL 回放响应的测试服务器
+// 同一请求被录制多次时（如轮询 /history）按录制顺序依次返回，用完后重复最后一条
+func ReplayServer(recordingDir string) (*httptest.Server, error) {
+	files, err := filepath.Glob(filepath.Join(recordingDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	exchanges := make(map[string][]*recordedExchange)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var ex recordedExchange
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return nil, fmt.Errorf("comfyui replay %s: %w", filepath.Base(f), err)
+		}
+		key := ex.Method + " " + ex.URL
+		exchanges[key] = append(exchanges[key], &ex)
+	}
+	if len(exchanges) == 0 {
+		return nil, fmt.Errorf("comfyui replay: no recordings in %s", recordingDir)
+	}
+
+	var mu sync.Mutex
+	served := make(map[string]int)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.RequestURI()
+		mu.Lock()
+		list := exchanges[key]
+		var ex *recordedExchange
+		if len(list) > 0 {
+			i := served[key]
+			if i >= len(list) {
+				i = len(list) - 1
+			}
+			ex = list[i]
+			served[key] = i + 1
+		}
+		mu.Unlock()
+		if ex == nil {
+			http.Error(w, "no recording for "+key, http.StatusNotFound)
+			return
+		}
+		for k, vs := range ex.Header {
+			if k == "Content-Length" {
+				continue
+			}
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(ex.Status)
+		_, _ = io.WriteString(w, ex.Body)
+	})), nil
+}
@@ -0,0 +1,49 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrHistoryNotFound history 中没有该 prompt 的记录（从未提交，或已被服务器清理）
+var ErrHistoryNotFound = errors.New("comfyui prompt not found in history")
+
+// Workflow 返回提交时的 API 格式工作流（history 记录 prompt 字段的第 3 项），记录中没有时返回 false
+func (e *HistoryEntry) Workflow() (map[string]interface{}, bool) {
+	var history map[string]interface{}
+	if err := json.Unmarshal(e.Raw, &history); err != nil {
+		return nil, false
+	}
+	wf := historyWorkflow(history)
+	return wf, wf != nil
+}
+
+// historyWorkflow 从 history 记录的 prompt（[number, prompt_id, workflow, extra_data, outputs]）中取出工作流
+func historyWorkflow(history map[string]interface{}) map[string]interface{} {
+	prompt, _ := history["prompt"].([]interface{})
+	if len(prompt) < 3 {
+		return nil
+	}
+	workflow, _ := prompt[2].(map[string]interface{})
+	return workflow
+}
+
+// ResubmitFromHistory 取出 promptID 当时提交的工作流原样重新提交并等待结果，返回新的图片 URL；
+// 种子等参数都保存在工作流中，可精确复现历史生成。要在另一台服务器复现，可用源 Client 的 GetHistory
+// 与 HistoryEntry.Workflow 取出工作流，再交给目标 Client 的 GenerateFromWorkflow
+func (c *Client) ResubmitFromHistory(ctx context.Context, promptID string) (string, error) {
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", fmt.Errorf("%w: %s", ErrHistoryNotFound, promptID)
+	}
+	workflow, ok := entry.Workflow()
+	if !ok {
+		return "", fmt.Errorf("%w: prompt %s has no workflow", ErrHistoryNotFound, promptID)
+	}
+	return c.GenerateFromWorkflow(ctx, workflow)
+}
@@ -0,0 +1,66 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCacheMiss 缓存中没有该键
+	ErrCacheMiss = errors.New("comfyui result not cached")
+	// ErrCacheExpired 缓存的结果已超过 ResultTTL，ComfyUI 可能已清理对应文件，调用方应重新生成
+	ErrCacheExpired = errors.New("comfyui cached result expired")
+)
+
+type cachedResult struct {
+	url       string
+	expiresAt time.Time // 零值表示永不过期
+}
+
+// resultCache Generate 成功结果的内存缓存，键为 ParamsHash
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResult
+}
+
+func (rc *resultCache) store(key, url string, ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.entries == nil {
+		rc.entries = make(map[string]cachedResult)
+	}
+	entry := cachedResult{url: url}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	rc.entries[key] = entry
+}
+
+func (rc *resultCache) load(key string) (cachedResult, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	entry, ok := rc.entries[key]
+	return entry, ok
+}
+
+// CacheResult 以 key 缓存图片 URL，按 Client.ResultTTL 记录过期时间；Generate 成功后会自动以 ParamsHash 缓存
+func (c *Client) CacheResult(key, imageURL string) {
+	c.results.store(key, imageURL, c.ResultTTL)
+}
+
+// GetCachedResult 查询缓存的图片 URL；不存在返回 ErrCacheMiss，过期时同时返回旧 URL 与 ErrCacheExpired
+func (c *Client) GetCachedResult(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	entry, ok := c.results.load(key)
+	if !ok {
+		return "", ErrCacheMiss
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		return entry.url, ErrCacheExpired
+	}
+	return entry.url, nil
+}
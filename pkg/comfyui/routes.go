@@ -0,0 +1,75 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// StorageBackend 输出图片的存储后端；签名与 infrastructure/storage 的 Upload 一致，可直接传入本地或对象存储实现
+type StorageBackend interface {
+	Upload(file io.Reader, filename string, category string) (string, error)
+}
+
+// OutputRoute 把 NodeID 节点输出的全部图片上传到 Storage
+type OutputRoute struct {
+	NodeID  string
+	Storage StorageBackend
+}
+
+// outputRouteCategory 上传到 StorageBackend 时使用的 category
+const outputRouteCategory = "comfyui"
+
+// WithOutputRoutes 生成完成后按节点把输出上传到不同的存储，如原图写本地、放大图写 S3；
+// 对象按内容命名（"<sha256>.<扩展名>"），上传后的地址见 GenerateResult.RoutedURLs。任一上传失败时 Generate 返回错误
+func WithOutputRoutes(routes []OutputRoute) Option {
+	return func(c *Client) {
+		c.outputRoutes = append([]OutputRoute(nil), routes...)
+	}
+}
+
+type routedURLsKey struct{}
+
+// withRoutedURLs 让 routeOutputs 把上传结果（/view 地址 -> 存储地址）写入 routed
+func withRoutedURLs(ctx context.Context, routed map[string]string) context.Context {
+	return context.WithValue(ctx, routedURLsKey{}, routed)
+}
+
+// routeOutputs 按 outputRoutes 下载各节点的输出并上传；节点没有输出时跳过
+func (c *Client) routeOutputs(ctx context.Context, promptID string) error {
+	if len(c.outputRoutes) == 0 {
+		return nil
+	}
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%w: prompt %s", ErrNoOutput, promptID)
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return err
+	}
+	routed, _ := ctx.Value(routedURLsKey{}).(map[string]string)
+	for _, route := range c.outputRoutes {
+		for _, img := range entry.NodeImages(route.NodeID) {
+			imageURL, err := historyImageURL(baseURL, img)
+			if err != nil {
+				return err
+			}
+			data, err := c.fetch(ctx, imageURL)
+			if err != nil {
+				return fmt.Errorf("comfyui route output node %s: %w", route.NodeID, err)
+			}
+			storedURL, _, err := uploadByHash(route.Storage, data, img.Filename, outputRouteCategory)
+			if err != nil {
+				return fmt.Errorf("comfyui route output node %s: %w", route.NodeID, err)
+			}
+			if routed != nil {
+				routed[imageURL] = storedURL
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNSFWContent 安全检查节点判定输出图片不适宜公开
+var ErrNSFWContent = errors.New("comfyui output flagged as nsfw")
+
+// ErrSafetyCheckUnavailable 开启了安全检查，但安全检查节点没有给出明确的判定结果
+var ErrSafetyCheckUnavailable = errors.New("comfyui safety check result unavailable")
+
+// safetyCheckerNodeID Params.SafetyCheck 插入的安全检查节点
+const safetyCheckerNodeID = "80"
+
+// SafetyCheckerClass 安全检查节点的 class_type，需在 ComfyUI 上安装提供该节点的插件；
+// 节点输入 images，输出处理后的 IMAGE，并在 UI 输出中写入 "nsfw": [bool]
+var SafetyCheckerClass = "SafetyChecker"
+
+// addSafetyChecker 在 VAEDecode(5) 与 SaveImage(8) 之间插入安全检查节点(80)
+func addSafetyChecker(workflow map[string]interface{}) {
+	workflow[safetyCheckerNodeID] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"images": []interface{}{"5", 0}, "sensitivity": 0.5},
+		"class_type": SafetyCheckerClass,
+	}
+	save := workflow["8"].(map[string]interface{})["inputs"].(map[string]interface{})
+	save["images"] = []interface{}{safetyCheckerNodeID, 0}
+}
+
+type safetyCheckKey struct{}
+
+func withSafetyCheck(ctx context.Context, enabled bool) context.Context {
+	if !enabled {
+		return ctx
+	}
+	return context.WithValue(ctx, safetyCheckKey{}, true)
+}
+
+// checkSafety ctx 开启了安全检查时查询 history，安全检查节点标记为 nsfw 则返回 ErrNSFWContent；
+// 节点没有输出或没有明确给出 nsfw: false 时按不安全处理，返回 ErrSafetyCheckUnavailable
+func (c *Client) checkSafety(ctx context.Context, promptID string) error {
+	if enabled, _ := ctx.Value(safetyCheckKey{}).(bool); !enabled {
+		return nil
+	}
+	entry, err := c.GetHistory(ctx, promptID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%w: prompt %s not in history", ErrSafetyCheckUnavailable, promptID)
+	}
+	flagged, err := nsfwFlagged(entry.Raw)
+	if err != nil {
+		return err
+	}
+	if flagged {
+		return ErrNSFWContent
+	}
+	return nil
+}
+
+// nsfwFlagged 读取 outputs.<80>.nsfw，兼容 [true] 与 true 两种写法；字段缺失、为空或无法解析时返回 ErrSafetyCheckUnavailable
+func nsfwFlagged(raw json.RawMessage) (bool, error) {
+	var history struct {
+		Outputs map[string]struct {
+			NSFW json.RawMessage `json:"nsfw"`
+		} `json:"outputs"`
+	}
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrSafetyCheckUnavailable, err)
+	}
+	output, ok := history.Outputs[safetyCheckerNodeID]
+	if !ok || len(output.NSFW) == 0 {
+		return false, fmt.Errorf("%w: node %s reported no nsfw verdict", ErrSafetyCheckUnavailable, safetyCheckerNodeID)
+	}
+	var list []bool
+	if json.Unmarshal(output.NSFW, &list) == nil && len(list) > 0 {
+		for _, b := range list {
+			if b {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	var b bool
+	if json.Unmarshal(output.NSFW, &b) == nil {
+		return b, nil
+	}
+	return false, fmt.Errorf("%w: unrecognised nsfw value %s", ErrSafetyCheckUnavailable, output.NSFW)
+}
@@ -0,0 +1,65 @@
+package comfyui
+
+// SamplerConfig KSampler 采样参数
+type SamplerConfig struct {
+	Steps     int     `json:"steps" yaml:"steps"`
+	CFG       float64 `json:"cfg" yaml:"cfg"`
+	Sampler   string  `json:"sampler" yaml:"sampler"`     // sampler_name，如 euler、dpmpp_2m
+	Scheduler string  `json:"scheduler" yaml:"scheduler"` // 如 beta、karras
+	Denoise   float64 `json:"denoise" yaml:"denoise"`
+}
+
+// 常用采样预设
+var (
+	SamplerFast    = SamplerConfig{Steps: 15, CFG: 1, Sampler: "euler", Scheduler: "beta", Denoise: 1}
+	SamplerQuality = SamplerConfig{Steps: 30, CFG: 1, Sampler: "dpmpp_2m", Scheduler: "karras", Denoise: 1}
+	SamplerFlux    = SamplerConfig{Steps: 25, CFG: 1, Sampler: "euler", Scheduler: "beta", Denoise: 1}
+)
+
+// samplerPresets Params.SamplerPreset 可选值
+var samplerPresets = map[string]SamplerConfig{
+	"fast":    SamplerFast,
+	"quality": SamplerQuality,
+	"flux":    SamplerFlux,
+}
+
+// applyPreset 用预设补齐未填写的字段，未指定或未知预设时按 Flux 默认值
+func (s *SamplerConfig) applyPreset(name string) {
+	preset, ok := samplerPresets[name]
+	if !ok {
+		preset = SamplerFlux
+	}
+	if s.Steps <= 0 {
+		s.Steps = preset.Steps
+	}
+	if s.CFG <= 0 {
+		s.CFG = preset.CFG
+	}
+	if s.Sampler == "" {
+		s.Sampler = preset.Sampler
+	}
+	if s.Scheduler == "" {
+		s.Scheduler = preset.Scheduler
+	}
+	if s.Denoise <= 0 {
+		s.Denoise = preset.Denoise
+	}
+}
+
+// modelFamilyDefaults 各模型系列推荐的 CFG / 步数
+var modelFamilyDefaults = map[string]SamplerConfig{
+	"flux": {Steps: 20, CFG: 1},
+	"sdxl": {Steps: 30, CFG: 7.5},
+	"sd15": {Steps: 20, CFG: 7},
+}
+
+// NormalizeForModel 按模型系列（flux / sdxl / sd15）覆盖 CFG 与步数，返回新的 Params；
+// 避免用 Flux 的 CFG=1 跑 SDXL 之类的误用，未知系列原样返回副本
+func NormalizeForModel(p *Params, modelFamily string) *Params {
+	out := *p
+	if d, ok := modelFamilyDefaults[modelFamily]; ok {
+		out.CFG = d.CFG
+		out.Steps = d.Steps
+	}
+	return &out
+}
@@ -0,0 +1,80 @@
+package comfyui
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SceneDescription 剧本中的结构化场景信息
+type SceneDescription struct {
+	Location   string
+	TimeOfDay  string // dawn / day / dusk / night，也接受 清晨 / 白天 / 黄昏 / 夜晚
+	Characters []string
+	Mood       string // tense / romantic / sad / joyful / mysterious 等
+	Action     string
+}
+
+// timeOfDayLighting 时间段对应的光线描述
+var timeOfDayLighting = map[string]string{
+	"dawn":  "soft dawn light, pale blue and pink sky",
+	"清晨":    "soft dawn light, pale blue and pink sky",
+	"day":   "bright natural daylight",
+	"白天":    "bright natural daylight",
+	"dusk":  "golden hour, warm low sunlight, long shadows",
+	"黄昏":    "golden hour, warm low sunlight, long shadows",
+	"night": "night scene, moonlight, practical lights, deep shadows",
+	"夜晚":    "night scene, moonlight, practical lights, deep shadows",
+}
+
+// moodStyle 情绪对应的画面风格描述
+var moodStyle = map[string]string{
+	"tense":      "high contrast, dutch angle, cold color grading",
+	"紧张":         "high contrast, dutch angle, cold color grading",
+	"romantic":   "soft focus, warm pastel tones, bokeh",
+	"浪漫":         "soft focus, warm pastel tones, bokeh",
+	"sad":        "desaturated colors, overcast, muted tones",
+	"悲伤":         "desaturated colors, overcast, muted tones",
+	"joyful":     "vibrant saturated colors, cheerful atmosphere",
+	"欢快":         "vibrant saturated colors, cheerful atmosphere",
+	"mysterious": "fog, volumetric light, low key lighting",
+	"神秘":         "fog, volumetric light, low key lighting",
+}
+
+var scenePromptTemplate = template.Must(template.New("scene").Parse(
+	`cinematic drama still, {{.Location}}` +
+		`{{if .Characters}}, {{.Characters}}{{end}}` +
+		`{{if .Action}}, {{.Action}}{{end}}` +
+		`{{if .Lighting}}, {{.Lighting}}{{end}}` +
+		`{{if .Style}}, {{.Style}}{{end}}` +
+		`, film grain, highly detailed`))
+
+// ScenePrompt 由结构化场景生成文生图 Params：TimeOfDay 决定光线关键词，Mood 决定风格关键词，
+// 未登记的取值原样写入 prompt
+func ScenePrompt(scene SceneDescription) (*Params, error) {
+	if strings.TrimSpace(scene.Location) == "" {
+		return nil, fmt.Errorf("comfyui scene location is required")
+	}
+	data := struct {
+		Location, Characters, Action, Lighting, Style string
+	}{
+		Location:   scene.Location,
+		Characters: strings.Join(scene.Characters, ", "),
+		Action:     scene.Action,
+		Lighting:   lookupKeywords(timeOfDayLighting, scene.TimeOfDay),
+		Style:      lookupKeywords(moodStyle, scene.Mood),
+	}
+	var sb strings.Builder
+	if err := scenePromptTemplate.Execute(&sb, data); err != nil {
+		return nil, fmt.Errorf("comfyui render scene prompt: %w", err)
+	}
+	return &Params{Prompt: sb.String(), Width: 1920, Height: 1080}, nil
+}
+
+func lookupKeywords(table map[string]string, key string) string {
+	key = strings.TrimSpace(key)
+	if kw, ok := table[strings.ToLower(key)]; ok {
+		return kw
+	}
+	return key
+}
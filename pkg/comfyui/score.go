@@ -0,0 +1,86 @@
+package comfyui
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInsufficientVRAM 工作流预估显存超过 Client.AvailableVRAMGB
+var ErrInsufficientVRAM = errors.New("comfyui workflow exceeds available vram")
+
+// WorkflowScore 工作流复杂度评估结果
+type WorkflowScore struct {
+	EstimatedVRAMGB float64
+	NodeCount       int
+	HasControlNet   bool
+	HasLoRA         bool
+	HasHiresFix     bool
+}
+
+// nodeVRAMGB 各类节点的大致显存占用（GB），按 Flux fp8 实测粗略取值，未列出的节点忽略不计
+var nodeVRAMGB = map[string]float64{
+	"UNETLoader":             11.5,
+	"CheckpointLoaderSimple": 6.5,
+	"DualCLIPLoader":         5.0,
+	"CLIPLoader":             1.5,
+	"VAELoader":              0.3,
+	"LoraLoader":             0.4,
+	"LoraLoaderModelOnly":    0.4,
+	"ControlNetLoader":       2.5,
+	"LoadFluxIPAdapter":      1.2,
+	"UpscaleModelLoader":     0.5,
+	"VAEDecode":              0.5,
+	"VAEEncode":              0.5,
+}
+
+// latentVRAMGBPerMP 每百万像素 latent 在采样时的额外显存
+const latentVRAMGBPerMP = 0.8
+
+// ScoreWorkflow 根据工作流中的节点类型估算显存占用
+func ScoreWorkflow(wf map[string]interface{}) *WorkflowScore {
+	score := &WorkflowScore{NodeCount: len(wf)}
+	samplers := 0
+	for _, raw := range wf {
+		node, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		classType, _ := node["class_type"].(string)
+		score.EstimatedVRAMGB += nodeVRAMGB[classType]
+		switch {
+		case strings.HasPrefix(classType, "ControlNet"):
+			score.HasControlNet = true
+		case strings.HasPrefix(classType, "Lora"):
+			score.HasLoRA = true
+		case strings.HasPrefix(classType, "KSampler") || strings.HasPrefix(classType, "SamplerCustom"):
+			samplers++
+		case strings.Contains(classType, "Upscale"):
+			score.HasHiresFix = true
+		}
+		if classType == "EmptyLatentImage" {
+			inputs, _ := node["inputs"].(map[string]interface{})
+			w, h, batch := toFloat(inputs["width"]), toFloat(inputs["height"]), toFloat(inputs["batch_size"])
+			if batch <= 0 {
+				batch = 1
+			}
+			score.EstimatedVRAMGB += w * h * batch / 1e6 * latentVRAMGBPerMP
+		}
+	}
+	// 两次以上采样（先生成再放大重采样）视为 hires fix
+	if samplers > 1 {
+		score.HasHiresFix = true
+	}
+	return score
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ShardedUNETLoaderClass 按分片加载 UNET 的节点，需在 ComfyUI 上安装提供该节点的插件；
+// 输入 shard_names 为换行分隔的分片文件名（与 UNETLoader 相同的 diffusion_models 目录）
+const ShardedUNETLoaderClass = "ShardedUNETLoader"
+
+// ErrNodeUnavailable 服务器上没有工作流所需的节点类型
+var ErrNodeUnavailable = errors.New("comfyui node type not available on server")
+
+// applyUNETShards 把 UNETLoader(17) 换成 ShardedUNETLoader，输出与 UNETLoader 相同
+func applyUNETShards(workflow map[string]interface{}, shards []string) {
+	workflow["17"] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"shard_names": strings.Join(shards, "\n"), "weight_dtype": "fp8_e4m3fn"},
+		"class_type": ShardedUNETLoaderClass,
+	}
+}
+
+// requireNode 查询 /object_info/{class}，节点未注册时 ComfyUI 返回空对象
+func (c *Client) requireNode(ctx context.Context, classType string) error {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return err
+	}
+	var info map[string]json.RawMessage
+	found, err := c.getJSON(ctx, baseURL+"/object_info/"+url.PathEscape(classType), &info)
+	if err != nil {
+		return fmt.Errorf("comfyui object_info: %w", err)
+	}
+	if _, ok := info[classType]; !found || !ok {
+		return fmt.Errorf("%w: %s", ErrNodeUnavailable, classType)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollSnapshot 一次 history 轮询的记录
+type pollSnapshot struct {
+	PromptID string          `json:"prompt_id"`
+	Poll     int             `json:"poll"`
+	Time     time.Time       `json:"time"`
+	History  json.RawMessage `json:"history,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// writePollSnapshot 写入 SnapshotDir/<prompt_id>/<时间戳>_<轮次>.json；写入失败只记录警告，不影响生成
+func (c *Client) writePollSnapshot(promptID string, poll int, entry *HistoryEntry, pollErr error) {
+	now := time.Now()
+	snap := pollSnapshot{PromptID: promptID, Poll: poll, Time: now}
+	if entry != nil {
+		snap.History = entry.Raw
+	}
+	if pollErr != nil {
+		snap.Error = pollErr.Error()
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		c.warnw("ComfyUI marshal poll snapshot failed", "prompt_id", promptID, "error", err)
+		return
+	}
+	dir := filepath.Join(c.SnapshotDir, filepath.Base(promptID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		c.warnw("ComfyUI create snapshot dir failed", "dir", dir, "error", err)
+		return
+	}
+	name := fmt.Sprintf("%s_%03d.json", now.UTC().Format("20060102T150405.000000000"), poll)
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		c.warnw("ComfyUI write poll snapshot failed", "prompt_id", promptID, "error", err)
+	}
+}
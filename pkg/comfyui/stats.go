@@ -0,0 +1,84 @@
+package comfyui
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const statsWindowSize = 1000
+
+// ExecutionStats Generate 调用统计，分位数基于最近 1000 次调用，计数为累计值
+type ExecutionStats struct {
+	P50           time.Duration
+	P95           time.Duration
+	P99           time.Duration
+	MaxDuration   time.Duration
+	TotalRequests int64
+	SuccessCount  int64
+	FailureCount  int64
+	LastUpdated   time.Time
+}
+
+// executionStats 环形缓冲区记录最近 statsWindowSize 次调用耗时
+type executionStats struct {
+	mu        sync.Mutex
+	ring      []time.Duration
+	next      int
+	success   int64
+	failure   int64
+	updatedAt time.Time
+}
+
+func (s *executionStats) record(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.ring) < statsWindowSize {
+		s.ring = append(s.ring, d)
+	} else {
+		s.ring[s.next] = d
+	}
+	s.next = (s.next + 1) % statsWindowSize
+	if err != nil {
+		s.failure++
+	} else {
+		s.success++
+	}
+	s.updatedAt = time.Now()
+}
+
+func (s *executionStats) snapshot() ExecutionStats {
+	s.mu.Lock()
+	sorted := append([]time.Duration(nil), s.ring...)
+	stats := ExecutionStats{
+		TotalRequests: s.success + s.failure,
+		SuccessCount:  s.success,
+		FailureCount:  s.failure,
+		LastUpdated:   s.updatedAt,
+	}
+	s.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return stats
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.P50 = percentile(sorted, 50)
+	stats.P95 = percentile(sorted, 95)
+	stats.P99 = percentile(sorted, 99)
+	stats.MaxDuration = sorted[len(sorted)-1]
+	return stats
+}
+
+// percentile 最近秩法，sorted 须已升序且非空
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (len(sorted)*p+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// Stats 返回 Generate 的延迟分位数与成功/失败计数，用于 SLA 报表
+func (c *Client) Stats() ExecutionStats {
+	return c.stats.snapshot()
+}
@@ -0,0 +1,79 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrNoStepPreviews 没有记录到该 prompt 的预览帧（未开启 RecordStepPreviews、未走 WebSocket，
+// 或 ComfyUI 启动时未指定 --preview-method）
+var ErrNoStepPreviews = errors.New("comfyui no step previews recorded")
+
+// maxPreviewPrompts 最多保留最近多少个 prompt 的预览帧
+const maxPreviewPrompts = 16
+
+// wsBinaryPreviewImage /ws 二进制消息的事件类型：前 4 字节为事件类型，PREVIEW_IMAGE 时紧跟 4 字节图片格式（1 JPEG / 2 PNG）
+const wsBinaryPreviewImage = 1
+
+type stepPreview struct {
+	step int
+	data []byte
+}
+
+// stepPreviewLog 按 prompt 保存 WebSocket 收到的采样预览帧，超过 maxPreviewPrompts 时丢弃最早的 prompt
+type stepPreviewLog struct {
+	mu      sync.Mutex
+	order   []string
+	entries map[string][]stepPreview
+}
+
+func (l *stepPreviewLog) add(promptID string, step int, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.entries == nil {
+		l.entries = make(map[string][]stepPreview)
+	}
+	if _, ok := l.entries[promptID]; !ok {
+		l.order = append(l.order, promptID)
+		if len(l.order) > maxPreviewPrompts {
+			delete(l.entries, l.order[0])
+			l.order = l.order[1:]
+		}
+	}
+	l.entries[promptID] = append(l.entries[promptID], stepPreview{step: step, data: data})
+}
+
+func (l *stepPreviewLog) get(promptID string) []stepPreview {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]stepPreview(nil), l.entries[promptID]...)
+}
+
+// parsePreviewFrame 解析 /ws 的二进制预览帧，返回去掉 8 字节头的图片内容
+func parsePreviewFrame(raw []byte) ([]byte, bool) {
+	if len(raw) <= 8 || binary.BigEndian.Uint32(raw[:4]) != wsBinaryPreviewImage {
+		return nil, false
+	}
+	return raw[8:], true
+}
+
+// FetchStepPreviews 返回 WebSocket 生成期间记录的逐步预览帧（默认 JPEG），按采样步数排序；
+// 需开启 Client.RecordStepPreviews，只保留最近 16 个 prompt
+func (c *Client) FetchStepPreviews(ctx context.Context, promptID string) ([][]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	previews := c.previews.get(promptID)
+	if len(previews) == 0 {
+		return nil, ErrNoStepPreviews
+	}
+	sort.SliceStable(previews, func(i, j int) bool { return previews[i].step < previews[j].step })
+	frames := make([][]byte, len(previews))
+	for i, p := range previews {
+		frames[i] = p.data
+	}
+	return frames, nil
+}
@@ -0,0 +1,145 @@
+package comfyui
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage 通过 S3 PutObject 接口（AWS Signature V4）上传的 StorageBackend，不依赖 SDK；
+// 适用于 AWS S3、MinIO 以及阿里云 OSS 等兼容 S3 的对象存储（OSS 需使用虚拟主机风格，即 VirtualHostStyle 为 true）
+type S3Storage struct {
+	Endpoint  string // 如 https://s3.ap-east-1.amazonaws.com、https://oss-cn-hangzhou.aliyuncs.com
+	Region    string // 如 ap-east-1、oss-cn-hangzhou
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefix 对象键前缀，对象键为 Prefix + category + "/" + filename（WithResultStorage 传入的 filename 为内容 SHA-256）
+	Prefix string
+	// VirtualHostStyle 为 true 时请求 https://<bucket>.<endpoint host>/<key>，否则为 <endpoint>/<bucket>/<key>
+	VirtualHostStyle bool
+	// PublicBaseURL 非空时返回 PublicBaseURL/<key>（CDN 等），否则返回对象的请求地址
+	PublicBaseURL string
+	HTTP          *http.Client
+}
+
+func (s *S3Storage) Upload(file io.Reader, filename string, category string) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	key := s.Prefix + category + "/" + path.Base(filename)
+	objectURL := s.objectURL(key)
+	req, err := http.NewRequest("PUT", objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(data))
+	s.sign(req, data, time.Now().UTC())
+	httpClient := s.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("s3 put %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("s3 put %s: %s: %s", key, resp.Status, string(b))
+	}
+	if s.PublicBaseURL != "" {
+		return strings.TrimRight(s.PublicBaseURL, "/") + "/" + key, nil
+	}
+	return objectURL, nil
+}
+
+func (s *S3Storage) objectURL(key string) string {
+	endpoint := strings.TrimRight(s.Endpoint, "/")
+	if s.VirtualHostStyle {
+		scheme, host, _ := strings.Cut(endpoint, "://")
+		return scheme + "://" + s.Bucket + "." + host + "/" + s3EscapePath(key)
+	}
+	return endpoint + "/" + s.Bucket + "/" + s3EscapePath(key)
+}
+
+// sign 按 AWS Signature V4 签名，签名头为 host、x-amz-content-sha256、x-amz-date
+func (s *S3Storage) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// s3CanonicalQuery SigV4 的规范查询串：参数按名称（同名按值）排序，名称与值均百分号编码，无值参数写作 "name="
+func s3CanonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(name, "")+"="+s3Escape(v, ""))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath 除 A-Z a-z 0-9 - _ . ~ 与路径分隔符 / 外全部百分号编码，与 SigV4 的规范 URI 一致
+func s3EscapePath(key string) string {
+	return s3Escape(key, "/")
+}
+
+// s3Escape 除 A-Z a-z 0-9 - _ . ~ 与 keep 中的字符外全部百分号编码
+func s3Escape(s, keep string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || strings.IndexByte("-_.~", ch) >= 0 || strings.IndexByte(keep, ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
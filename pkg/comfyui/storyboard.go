@@ -0,0 +1,81 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+)
+
+// SceneParams 分镜中单个镜头的参数；PreviousSceneURL 为上一镜头图片（须为 BaseURL 所在主机的地址），作为 IP-Adapter 参考图保持角色/画风一致
+type SceneParams struct {
+	Params
+	PreviousSceneURL string
+}
+
+// GenerateStoryboard 依次生成分镜图；第 2..N 张未指定 PreviousSceneURL 时自动使用上一张的结果
+func (c *Client) GenerateStoryboard(ctx context.Context, scenes []SceneParams) ([]string, error) {
+	urls := make([]string, 0, len(scenes))
+	for i := range scenes {
+		if err := ctx.Err(); err != nil {
+			return urls, err
+		}
+		scene := &scenes[i]
+		refURL := scene.PreviousSceneURL
+		if refURL == "" && i > 0 {
+			refURL = urls[i-1]
+		}
+		var patch func(map[string]interface{}) error
+		if refURL != "" {
+			patch = func(workflow map[string]interface{}) error {
+				return c.attachIPAdapter(ctx, workflow, refURL)
+			}
+		}
+		imageURL, err := c.generate(ctx, &scene.Params, patch, nil)
+		if err != nil {
+			return urls, fmt.Errorf("comfyui storyboard scene %d: %w", i+1, err)
+		}
+		urls = append(urls, imageURL)
+	}
+	return urls, nil
+}
+
+// attachIPAdapter 上传参考图并插入 LoadImage(30) -> LoadFluxIPAdapter(31) -> ApplyFluxIPAdapter(32)，KSampler 改用 32 输出的模型（输入为 KSampler 原来的模型，保留已串联的 LoRA）
+func (c *Client) attachIPAdapter(ctx context.Context, workflow map[string]interface{}, refURL string) error {
+	name, err := c.uploadFromURL(ctx, refURL)
+	if err != nil {
+		return err
+	}
+	return insertIPAdapter(workflow, name, defaultIPAdapterScale)
+}
+
+// defaultIPAdapterScale 参考图对生成结果的影响强度
+const defaultIPAdapterScale = 0.6
+
+// insertIPAdapter attachIPAdapter 的节点插入部分，image 为 input 目录中的文件名
+func insertIPAdapter(workflow map[string]interface{}, image string, scale float64) error {
+	model, err := samplerModel(workflow)
+	if err != nil {
+		return err
+	}
+	workflow["30"] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"image": image},
+		"class_type": "LoadImage",
+	}
+	workflow["31"] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"ipadatper":   "flux-ip-adapter.safetensors",
+			"clip_vision": "clip_vision_l.safetensors",
+			"provider":    "CUDA",
+		},
+		"class_type": "LoadFluxIPAdapter",
+	}
+	workflow["32"] = map[string]interface{}{
+		"inputs": map[string]interface{}{
+			"model":           model,
+			"ip_adapter_flux": []interface{}{"31", 0},
+			"image":           []interface{}{"30", 0},
+			"ip_scale":        scale,
+		},
+		"class_type": "ApplyFluxIPAdapter",
+	}
+	return setSamplerModel(workflow, []interface{}{"32", 0})
+}
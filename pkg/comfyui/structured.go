@@ -0,0 +1,69 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+)
+
+// ImageInfo GenerateStructured 返回的单张输出图片
+type ImageInfo struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Base64    string `json:"base64"`
+}
+
+// GenerateStructured 生成并返回所有输出节点的图片（节点 ID -> 图片列表，如 "8" 为 SaveImage），
+// 每张图片都会下载并附带 base64，适合有多个 SaveImage 节点、需要分别路由输出的流水线
+func (c *Client) GenerateStructured(ctx context.Context, p *Params) (map[string][]ImageInfo, error) {
+	workflow, err := c.prepareWorkflow(ctx, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.waitForEntry(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+	return c.collectImages(ctx, entry)
+}
+
+// collectImages 下载 history 记录中的全部输出图片
+func (c *Client) collectImages(ctx context.Context, entry *HistoryEntry) (map[string][]ImageInfo, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs := make([]string, 0, len(entry.Outputs))
+	for id := range entry.Outputs {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+
+	out := make(map[string][]ImageInfo, len(nodeIDs))
+	for _, id := range nodeIDs {
+		for _, img := range entry.Outputs[id].Images {
+			imageURL, err := historyImageURL(baseURL, img)
+			if err != nil {
+				return nil, err
+			}
+			data, err := c.fetch(ctx, imageURL)
+			if err != nil {
+				return nil, err
+			}
+			out[id] = append(out[id], ImageInfo{
+				Filename:  img.Filename,
+				Subfolder: img.Subfolder,
+				Type:      img.Type,
+				URL:       imageURL,
+				Base64:    base64.StdEncoding.EncodeToString(data),
+			})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,172 @@
+package comfyui
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// SVG 布局尺寸（像素）
+const (
+	svgNodeWidth   = 180
+	svgNodeMinH    = 60
+	svgPortSpacing = 20
+	svgColumnGap   = 80
+	svgRowGap      = 30
+	svgMargin      = 20
+	svgPortRadius  = 5
+)
+
+// svgEdge 一条连线：源节点的第 output 个输出 -> 目标节点的 input 输入
+type svgEdge struct {
+	src, dst string
+	output   int
+	input    string
+}
+
+// svgBox 节点在画布上的位置与端口坐标
+type svgBox struct {
+	x, y, h int
+	inputs  map[string]int // 输入名 -> 端口 y 坐标
+	outputs map[int]int    // 输出序号 -> 端口 y 坐标
+}
+
+// RenderWorkflowSVG 把 API 格式工作流画成 SVG：节点为标注 class_type 的矩形，按依赖深度从左到右分列，
+// 连线为带箭头的线段，左侧圆点为输入端口、右侧为输出端口；结果不含 XML 声明，可直接嵌入 HTML
+func RenderWorkflowSVG(wf map[string]interface{}) ([]byte, error) {
+	nodes := make(map[string]map[string]interface{}, len(wf))
+	for id, v := range wf {
+		node, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("comfyui invalid node %s", id)
+		}
+		nodes[id] = node
+	}
+	ids := sortedNodeIDs(nodes)
+
+	var edges []svgEdge
+	incoming := map[string][]svgEdge{}
+	for _, id := range ids {
+		inputs, _ := nodes[id]["inputs"].(map[string]interface{})
+		for _, name := range sortedKeys(inputs) {
+			src, idx, ok := parseWire(inputs[name])
+			if _, exists := nodes[src]; !ok || !exists {
+				continue
+			}
+			e := svgEdge{src: src, dst: id, output: idx, input: name}
+			edges = append(edges, e)
+			incoming[id] = append(incoming[id], e)
+		}
+	}
+
+	depths, err := workflowDepths(ids, incoming)
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string][]int{}
+	for _, e := range edges {
+		outputs[e.src] = append(outputs[e.src], e.output)
+	}
+
+	boxes := make(map[string]*svgBox, len(ids))
+	columnY := map[int]int{}
+	width, height := 0, 0
+	for _, id := range ids {
+		outs := uniqueSortedInts(outputs[id])
+		ports := max(len(incoming[id]), len(outs))
+		box := &svgBox{
+			x:       svgMargin + depths[id]*(svgNodeWidth+svgColumnGap),
+			y:       svgMargin + columnY[depths[id]],
+			h:       max(svgNodeMinH, (ports+1)*svgPortSpacing),
+			inputs:  map[string]int{},
+			outputs: map[int]int{},
+		}
+		for i, e := range incoming[id] {
+			box.inputs[e.input] = box.y + box.h*(i+1)/(len(incoming[id])+1)
+		}
+		for i, idx := range outs {
+			box.outputs[idx] = box.y + box.h*(i+1)/(len(outs)+1)
+		}
+		columnY[depths[id]] += box.h + svgRowGap
+		boxes[id] = box
+		width = max(width, box.x+svgNodeWidth+svgMargin)
+		height = max(height, box.y+box.h+svgMargin)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	buf.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#666"/></marker></defs>` + "\n")
+	for _, e := range edges {
+		src, dst := boxes[e.src], boxes[e.dst]
+		fmt.Fprintf(&buf, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#666" stroke-width="1.5" marker-end="url(#arrow)"/>`+"\n",
+			src.x+svgNodeWidth, src.outputs[e.output], dst.x-svgPortRadius, dst.inputs[e.input])
+	}
+	for _, id := range ids {
+		box := boxes[id]
+		fmt.Fprintf(&buf, `<g id="node-%s">`+"\n", svgEscape(id))
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="#f5f7fa" stroke="#3b6ea5"/>`+"\n", box.x, box.y, svgNodeWidth, box.h)
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-weight="bold">%s</text>`+"\n", box.x+svgNodeWidth/2, box.y+18, svgEscape(classTypeOf(nodes[id])))
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" fill="#888">#%s</text>`+"\n", box.x+svgNodeWidth/2, box.y+34, svgEscape(id))
+		for _, e := range incoming[id] {
+			fmt.Fprintf(&buf, `<circle cx="%d" cy="%d" r="%d" fill="#3b6ea5"><title>%s</title></circle>`+"\n", box.x, box.inputs[e.input], svgPortRadius, svgEscape(e.input))
+		}
+		for _, idx := range uniqueSortedInts(outputs[id]) {
+			fmt.Fprintf(&buf, `<circle cx="%d" cy="%d" r="%d" fill="#e08a1e"><title>output %d</title></circle>`+"\n", box.x+svgNodeWidth, box.outputs[idx], svgPortRadius, idx)
+		}
+		buf.WriteString("</g>\n")
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+// workflowDepths 每个节点到最远上游源节点的距离，作为所在列；有环时返回错误
+func workflowDepths(ids []string, incoming map[string][]svgEdge) (map[string]int, error) {
+	depths := make(map[string]int, len(ids))
+	visiting := map[string]bool{}
+	var visit func(id string) (int, error)
+	visit = func(id string) (int, error) {
+		if d, ok := depths[id]; ok {
+			return d, nil
+		}
+		if visiting[id] {
+			return 0, fmt.Errorf("comfyui workflow has a cycle at node %s", id)
+		}
+		visiting[id] = true
+		depth := 0
+		for _, e := range incoming[id] {
+			d, err := visit(e.src)
+			if err != nil {
+				return 0, err
+			}
+			depth = max(depth, d+1)
+		}
+		depths[id] = depth
+		return depth, nil
+	}
+	for _, id := range ids {
+		if _, err := visit(id); err != nil {
+			return nil, err
+		}
+	}
+	return depths, nil
+}
+
+func uniqueSortedInts(values []int) []int {
+	seen := map[int]bool{}
+	var out []int
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+func svgEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,98 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// SystemStats GET /system_stats 的返回
+type SystemStats = api.SystemStats
+
+// DeviceStats 单块 GPU（或 CPU）的显存信息，单位字节
+type DeviceStats = api.DeviceStats
+
+// GetSystemStats 查询 /system_stats
+func (c *Client) GetSystemStats(ctx context.Context) (*SystemStats, error) {
+	cl, err := c.apiClient(false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cl.GetSystemStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("comfyui system_stats: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("comfyui system_stats %s", resp.Status)
+	}
+	var stats SystemStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("comfyui decode system_stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// ServerStatus WatchStatus 推送的服务器状态快照
+type ServerStatus struct {
+	Timestamp      time.Time
+	ComfyUIVersion string
+	QueueDepth     int // 执行中 + 排队中
+	VRAMFreeGB     float64
+	IsHealthy      bool
+	Err            error // 本次采集失败的原因，IsHealthy 为 false 时有值
+}
+
+// WatchStatus 每隔 interval 采集一次 /system_stats 与 /queue 并写入通道，ctx 结束后关闭通道
+func (c *Client) WatchStatus(ctx context.Context, interval time.Duration) (<-chan ServerStatus, error) {
+	if _, err := c.baseURL(); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("comfyui watch status interval must be positive")
+	}
+	ch := make(chan ServerStatus, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case ch <- c.collectStatus(ctx):
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Client) collectStatus(ctx context.Context) ServerStatus {
+	status := ServerStatus{Timestamp: time.Now()}
+	stats, err := c.GetSystemStats(ctx)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.ComfyUIVersion = stats.System.ComfyUIVersion
+	for _, d := range stats.Devices {
+		status.VRAMFreeGB += float64(d.VRAMFree) / (1 << 30)
+	}
+	queue, err := c.QueueStatus(ctx)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.QueueDepth = queue.RunningCount + queue.PendingCount
+	status.IsHealthy = true
+	return status
+}
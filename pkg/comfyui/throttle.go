@@ -0,0 +1,55 @@
+package comfyui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited 连续收到 429 Too Many Requests 超过 Client.MaxRateLimitRetries 次
+var ErrRateLimited = errors.New("comfyui rate limited")
+
+const (
+	defaultRateLimitDelay      = 5 * time.Second
+	defaultMaxRateLimitRetries = 3
+)
+
+// doThrottled 在 do 之上处理 429：按 Retry-After（秒数或 HTTP 日期，缺省 5s）等待后重试，
+// 连续超过 MaxRateLimitRetries 次返回 ErrRateLimited
+func (c *Client) doThrottled(req *http.Request) (*http.Response, error) {
+	maxRetries := c.MaxRateLimitRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRateLimitRetries
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err
+		}
+		delay := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		resp.Body.Close()
+		if attempt >= maxRetries {
+			return nil, fmt.Errorf("%w: %s %s after %d retries", ErrRateLimited, req.Method, req.URL.Path, attempt)
+		}
+		c.warnw("ComfyUI rate limited, backing off", "path", req.URL.Path, "attempt", attempt+1, "delay", delay.String())
+		if err := sleepCtx(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req, err = cloneRequest(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter 解析 Retry-After 头，无法解析或已过期时返回默认的 5s
+func retryAfter(header string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return defaultRateLimitDelay
+}
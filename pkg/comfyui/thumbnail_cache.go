@@ -0,0 +1,98 @@
+package comfyui
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"image"
+	"image/png"
+	"sync"
+)
+
+// defaultThumbnailCacheSize NewThumbnailCache 的默认容量
+const defaultThumbnailCacheSize = 256
+
+// thumbnailConfig WithThumbnail 的配置
+type thumbnailConfig struct {
+	maxSize int
+	cache   *ThumbnailCache
+}
+
+// ThumbnailCache 按图片地址缓存缩略图的 LRU 缓存，并发安全
+type ThumbnailCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // 队首为最近使用
+	entries  map[string]*list.Element
+}
+
+type thumbnailEntry struct {
+	imageURL  string
+	thumbnail []byte
+}
+
+// NewThumbnailCache 创建最多保存 capacity 张缩略图的缓存，capacity <= 0 时为 256
+func NewThumbnailCache(capacity int) *ThumbnailCache {
+	if capacity <= 0 {
+		capacity = defaultThumbnailCacheSize
+	}
+	return &ThumbnailCache{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get 返回 imageURL 的缩略图，并将其标记为最近使用
+func (tc *ThumbnailCache) Get(imageURL string) ([]byte, bool) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	el, ok := tc.entries[imageURL]
+	if !ok {
+		return nil, false
+	}
+	tc.order.MoveToFront(el)
+	return el.Value.(*thumbnailEntry).thumbnail, true
+}
+
+// Set 保存 imageURL 的缩略图，超出容量时淘汰最久未使用的一张
+func (tc *ThumbnailCache) Set(imageURL string, thumbnail []byte) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if el, ok := tc.entries[imageURL]; ok {
+		el.Value.(*thumbnailEntry).thumbnail = thumbnail
+		tc.order.MoveToFront(el)
+		return
+	}
+	tc.entries[imageURL] = tc.order.PushFront(&thumbnailEntry{imageURL: imageURL, thumbnail: thumbnail})
+	if tc.order.Len() > tc.capacity {
+		oldest := tc.order.Back()
+		tc.order.Remove(oldest)
+		delete(tc.entries, oldest.Value.(*thumbnailEntry).imageURL)
+	}
+}
+
+// makeThumbnail 按比例缩小到最长边不超过 maxSize（最近邻采样）并编码为 PNG；图片本身更小时只重新编码
+func makeThumbnail(data []byte, maxSize int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("comfyui decode image: %w", err)
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxSize > 0 && (w > maxSize || h > maxSize) {
+		if w >= h {
+			w, h = maxSize, max(1, h*maxSize/b.Dx())
+		} else {
+			w, h = max(1, w*maxSize/b.Dy()), maxSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy := b.Min.Y + y*b.Dy()/h
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/w, sy))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("comfyui encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,27 @@
+package comfyui
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceNodeID 写入 trace ID 的元数据节点
+const traceNodeID = "90"
+
+// buildWorkflowFromContext 构建工作流（配置了 WithDeadlineAdaptation 时先按 ctx 的截止时间调整步数），ctx 中带有 otel span 时额外加入一个 PrimitiveString 节点保存 trace ID。
+// 该节点不连接任何输出，不会被执行，但会随 prompt 一起保存在 ComfyUI history 中，便于和服务端链路关联
+func (c *Client) buildWorkflowFromContext(ctx context.Context, p *Params) map[string]interface{} {
+	c.adaptStepsToDeadline(ctx, p)
+	workflow := c.buildWorkflow(p)
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return workflow
+	}
+	workflow[traceNodeID] = map[string]interface{}{
+		"class_type": "PrimitiveString",
+		"inputs":     map[string]interface{}{"value": sc.TraceID().String()},
+		"_meta":      map[string]interface{}{"title": "trace_id"},
+	}
+	return workflow
+}
@@ -0,0 +1,48 @@
+package comfyui
+
+import (
+	"errors"
+	"strings"
+)
+
+// translations 各语言下哨兵错误的提示文案，按顺序匹配
+var translations = map[string][]struct {
+	err error
+	msg string
+}{
+	"zh": {
+		{ErrSubmitFailed, "ComfyUI 任务提交失败"},
+		{ErrPollingTimeout, "等待 ComfyUI 生成结果超时"},
+		{ErrNoOutput, "ComfyUI 任务已完成，但没有输出图片"},
+		{ErrNodeErrors, "ComfyUI 工作流节点执行失败"},
+	},
+}
+
+// localizedError 替换错误文案，Unwrap 返回原错误
+type localizedError struct {
+	msg string
+	err error
+}
+
+func (e *localizedError) Error() string { return e.msg }
+
+func (e *localizedError) Unwrap() error { return e.err }
+
+// localizeError 按 ErrorLocale 翻译已知错误，原始细节（HTTP 状态、节点信息等）附在译文之后
+func (c *Client) localizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, t := range translations[c.ErrorLocale] {
+		if !errors.Is(err, t.err) {
+			continue
+		}
+		msg := t.msg
+		detail := strings.TrimPrefix(strings.TrimPrefix(err.Error(), t.err.Error()), ": ")
+		if detail != "" && detail != err.Error() {
+			msg += "：" + detail
+		}
+		return &localizedError{msg: msg, err: err}
+	}
+	return err
+}
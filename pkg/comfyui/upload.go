@@ -0,0 +1,58 @@
+package comfyui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// UploadImage 通过 /upload/image 上传图片到 ComfyUI 的 input 目录，返回 LoadImage 可用的文件名
+func (c *Client) UploadImage(ctx context.Context, data []byte, filename string) (string, error) {
+	cl, err := c.apiClient(false)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("image", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return "", err
+	}
+	_ = mw.WriteField("overwrite", "true")
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	resp, err := cl.PostUploadImageWithBody(ctx, mw.FormDataContentType(), &buf)
+	if err != nil {
+		return "", fmt.Errorf("comfyui upload: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("comfyui upload %s: %s", resp.Status, string(b))
+	}
+	var uploadResp api.UploadResponse
+	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+		return "", fmt.Errorf("comfyui decode upload response: %w", err)
+	}
+	if uploadResp.Subfolder != "" {
+		return uploadResp.Subfolder + "/" + uploadResp.Name, nil
+	}
+	return uploadResp.Name, nil
+}
+
+// fetch 下载 ComfyUI 上的文件（如 /view 图片地址），与 DownloadImage 相同只允许 BaseURL 所在主机并受 MaxImageBytes 限制
+func (c *Client) fetch(ctx context.Context, fileURL string) ([]byte, error) {
+	data, _, err := c.downloadImage(ctx, fileURL)
+	return data, err
+}
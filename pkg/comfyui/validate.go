@@ -0,0 +1,136 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigError Client 的一项配置错误
+type ConfigError struct {
+	Field  string
+	Reason string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("comfyui config %s: %s", e.Field, e.Reason)
+}
+
+// ValidateClient 检查 Client 的常见配置错误并一次性返回全部问题，便于启动时报告，而不是首次 Generate 才失败；
+// 零值字段表示使用默认值，不视为错误
+func ValidateClient(c *Client) []ConfigError {
+	var errs []ConfigError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+	if c.BaseURL == "" {
+		add("BaseURL", "is empty")
+	} else if _, err := normalizeBaseURL(c.BaseURL); err != nil {
+		add("BaseURL", "%v", err)
+	}
+	if strings.ContainsAny(c.ClientID, " \t\r\n") {
+		add("ClientID", "must not contain whitespace, got %q", c.ClientID)
+	}
+	if c.PollInterval < 0 {
+		add("PollInterval", "must be positive, got %v", c.PollInterval)
+	}
+	if c.PollTimeout < 0 {
+		add("PollTimeout", "must be positive, got %v", c.PollTimeout)
+	} else if c.pollTimeout() <= c.pollEvery() {
+		add("PollTimeout", "must be greater than PollInterval (%v), got %v", c.pollEvery(), c.pollTimeout())
+	}
+	if c.MaxRetries < 0 {
+		add("MaxRetries", "must not be negative, got %d", c.MaxRetries)
+	}
+	if c.Concurrency < 0 {
+		add("Concurrency", "must be positive, got %d", c.Concurrency)
+	}
+	return errs
+}
+
+// nodeInfo GET /object_info 中一个节点类型的输入定义；每项输入形如 ["MODEL"]、["INT", {...}]，
+// 下拉框为 [["euler", "ddim"], {...}] 或新版的 ["COMBO", {"options": [...]}]
+type nodeInfo struct {
+	Input struct {
+		Required map[string]json.RawMessage `json:"required"`
+		Optional map[string]json.RawMessage `json:"optional"`
+	} `json:"input"`
+}
+
+// validateWorkflow 按 ComfyUI 提交时的规则在本地校验工作流：节点类型已注册、必填输入齐全、
+// 连线指向存在的节点、下拉框取值在可选列表中
+func validateWorkflow(workflow map[string]interface{}, info map[string]nodeInfo) []DryRunError {
+	var errs []DryRunError
+	for _, id := range sortedKeys(workflow) {
+		node, _ := workflow[id].(map[string]interface{})
+		classType, _ := node["class_type"].(string)
+		add := func(format string, args ...interface{}) {
+			errs = append(errs, DryRunError{NodeID: id, ClassType: classType, Message: fmt.Sprintf(format, args...)})
+		}
+		if classType == "" {
+			add("missing class_type")
+			continue
+		}
+		spec, ok := info[classType]
+		if !ok {
+			add("node type %s not available on server", classType)
+			continue
+		}
+		inputs, _ := node["inputs"].(map[string]interface{})
+		required := make([]string, 0, len(spec.Input.Required))
+		for name := range spec.Input.Required {
+			if _, ok := inputs[name]; !ok {
+				required = append(required, name)
+			}
+		}
+		sort.Strings(required)
+		for _, name := range required {
+			add("missing required input %q", name)
+		}
+		for _, name := range sortedKeys(inputs) {
+			value := inputs[name]
+			if link, ok := value.([]interface{}); ok && len(link) == 2 {
+				if src, _ := link[0].(string); workflow[src] == nil {
+					add("input %q links to missing node %v", name, link[0])
+				}
+				continue
+			}
+			raw, ok := spec.Input.Required[name]
+			if !ok {
+				raw = spec.Input.Optional[name]
+			}
+			if options := comboOptions(raw); options != nil && !options[fmt.Sprint(value)] {
+				add("value %v for input %q not in list", value, name)
+			}
+		}
+	}
+	return errs
+}
+
+// comboOptions 解析下拉框输入的可选值，非下拉框输入返回 nil
+func comboOptions(raw json.RawMessage) map[string]bool {
+	var spec []json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &spec) != nil || len(spec) == 0 {
+		return nil
+	}
+	var values []interface{}
+	if json.Unmarshal(spec[0], &values) != nil {
+		var typeName string
+		if json.Unmarshal(spec[0], &typeName) != nil || typeName != "COMBO" || len(spec) < 2 {
+			return nil
+		}
+		var config struct {
+			Options []interface{} `json:"options"`
+		}
+		if json.Unmarshal(spec[1], &config) != nil || config.Options == nil {
+			return nil
+		}
+		values = config.Options
+	}
+	options := make(map[string]bool, len(values))
+	for _, v := range values {
+		options[fmt.Sprint(v)] = true
+	}
+	return options
+}
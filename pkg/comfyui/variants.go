@@ -0,0 +1,110 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// VariantOriginal GenerateWithVariants 结果中原图（SaveImage 节点 8）的键
+const VariantOriginal = "original"
+
+// variantNodeBase 变体节点 ID 从 60 开始，每个变体占 10 个
+const (
+	variantNodeBase = 60
+	variantNodeSpan = 10
+)
+
+// WorkflowVariant 在基础工作流之上追加后处理节点，额外输出一张命名图片
+type WorkflowVariant struct {
+	Name string
+	// AddNodes 以解码后的原图 image 为输入追加节点，返回新增的输出节点 ID；
+	// baseID 为本变体可用的第一个节点 ID（可用 baseID ~ baseID+9）
+	AddNodes func(workflow map[string]interface{}, image NodeRef, baseID int) (outputNodeID string)
+}
+
+// UpscaleVariant 用放大模型（如 4x-UltraSharp.pth）输出高清海报
+func UpscaleVariant(name, upscaleModel string) WorkflowVariant {
+	return WorkflowVariant{Name: name, AddNodes: func(workflow map[string]interface{}, image NodeRef, baseID int) string {
+		loader, upscale, save := strconv.Itoa(baseID), strconv.Itoa(baseID+1), strconv.Itoa(baseID+2)
+		workflow[loader] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"model_name": upscaleModel},
+			"class_type": "UpscaleModelLoader",
+		}
+		workflow[upscale] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"upscale_model": []interface{}{loader, 0}, "image": image.wire()},
+			"class_type": "ImageUpscaleWithModel",
+		}
+		workflow[save] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"filename_prefix": "huobao_" + name, "images": []interface{}{upscale, 0}},
+			"class_type": "SaveImage",
+		}
+		return save
+	}}
+}
+
+// ThumbnailVariant 缩放到 width×height 输出缩略图
+func ThumbnailVariant(name string, width, height int) WorkflowVariant {
+	return WorkflowVariant{Name: name, AddNodes: func(workflow map[string]interface{}, image NodeRef, baseID int) string {
+		scale, save := strconv.Itoa(baseID), strconv.Itoa(baseID+1)
+		workflow[scale] = map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"image": image.wire(), "upscale_method": "lanczos",
+				"width": width, "height": height, "crop": "disabled",
+			},
+			"class_type": "ImageScale",
+		}
+		workflow[save] = map[string]interface{}{
+			"inputs":     map[string]interface{}{"filename_prefix": "huobao_" + name, "images": []interface{}{scale, 0}},
+			"class_type": "SaveImage",
+		}
+		return save
+	}}
+}
+
+// GenerateWithVariants 一次运行同时输出原图与各变体（如缩略图 + 高清海报），返回变体名 -> 图片 URL，
+// 原图的键为 VariantOriginal
+func (c *Client) GenerateWithVariants(ctx context.Context, p *Params, variants []WorkflowVariant) (map[string]string, error) {
+	seen := map[string]bool{VariantOriginal: true}
+	for _, v := range variants {
+		if v.Name == "" || seen[v.Name] || v.AddNodes == nil {
+			return nil, fmt.Errorf("comfyui invalid workflow variant %q", v.Name)
+		}
+		seen[v.Name] = true
+	}
+
+	outputs := map[string]string{VariantOriginal: "8"}
+	workflow, err := c.prepareWorkflow(ctx, p, func(workflow map[string]interface{}) error {
+		for i, v := range variants {
+			outputs[v.Name] = v.AddNodes(workflow, NodeRef{NodeID: "5", Output: 0}, variantNodeBase+i*variantNodeSpan)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.waitForEntry(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(outputs))
+	for name, nodeID := range outputs {
+		imgs := entry.NodeImages(nodeID)
+		if len(imgs) == 0 {
+			return nil, fmt.Errorf("comfyui variant %q (node %s) produced no image", name, nodeID)
+		}
+		if result[name], err = historyImageURL(baseURL, imgs[0]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
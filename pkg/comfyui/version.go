@@ -0,0 +1,9 @@
+package comfyui
+
+// Version 服务版本，构建时可通过 -ldflags "-X github.com/drama-generator/backend/pkg/comfyui.Version=1.2.3" 注入
+var Version = "dev"
+
+// defaultUserAgent 未设置 WithUserAgent 时请求携带的 User-Agent
+func defaultUserAgent() string {
+	return "huobao-drama/" + Version
+}
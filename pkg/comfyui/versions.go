@@ -0,0 +1,107 @@
+package comfyui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/drama-generator/backend/pkg/comfyui/api"
+)
+
+// 依赖服务器版本的功能，见 IsFeatureSupported
+const (
+	FeatureWebSocket            = "websocket"
+	FeatureFree                 = "free"
+	FeatureInterrupt            = "interrupt"
+	FeatureObjectInfoPagination = "object_info_pagination"
+)
+
+// featureMinVersions 各功能需要的最低 ComfyUI 版本（/system_stats 中的 comfyui_version）；
+// 不上报版本的旧服务器按 0.0.0 处理，只支持最低版本为 0.0.0 的功能
+var featureMinVersions = map[string]string{
+	FeatureWebSocket:            "0.0.0",
+	FeatureInterrupt:            "0.0.0",
+	FeatureFree:                 "0.1.0",
+	FeatureObjectInfoPagination: "0.3.0",
+}
+
+// ErrFeatureUnsupported 服务器版本不支持所调用的功能
+var ErrFeatureUnsupported = errors.New("comfyui feature not supported by server version")
+
+// IsFeatureSupported serverVersion 是否支持 feature，未知功能返回 false
+func IsFeatureSupported(serverVersion, feature string) bool {
+	minVersion, ok := featureMinVersions[feature]
+	if !ok {
+		return false
+	}
+	return compareVersions(serverVersion, minVersion) >= 0
+}
+
+// serverVersionCache 首次成功查询 /system_stats 后缓存服务器版本
+type serverVersionCache struct {
+	mu      sync.Mutex
+	version string
+	ok      bool
+}
+
+// serverVersion 返回缓存的服务器版本，查询失败时下次调用重试
+func (c *Client) serverVersion(ctx context.Context) (string, error) {
+	c.version.mu.Lock()
+	defer c.version.mu.Unlock()
+	if c.version.ok {
+		return c.version.version, nil
+	}
+	stats, err := c.GetSystemStats(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.version.version, c.version.ok = stats.System.ComfyUIVersion, true
+	return c.version.version, nil
+}
+
+// requireFeature 服务器不支持 feature 时返回 ErrFeatureUnsupported；版本查询失败时不拦截，由实际请求报错
+func (c *Client) requireFeature(ctx context.Context, feature string) error {
+	version, err := c.serverVersion(ctx)
+	if err != nil {
+		return nil
+	}
+	if !IsFeatureSupported(version, feature) {
+		return fmt.Errorf("%w: %s requires >= %s, server is %q", ErrFeatureUnsupported, feature, featureMinVersions[feature], version)
+	}
+	return nil
+}
+
+// Interrupt 中断当前正在执行的任务（POST /interrupt）
+func (c *Client) Interrupt(ctx context.Context) error {
+	if err := c.requireFeature(ctx, FeatureInterrupt); err != nil {
+		return err
+	}
+	return c.callAPI("/interrupt", func(cl *api.Client) (*http.Response, error) {
+		return cl.PostInterrupt(ctx, api.PostInterruptJSONRequestBody{})
+	})
+}
+
+// Free 卸载模型 / 释放显存缓存（POST /free）
+func (c *Client) Free(ctx context.Context, unloadModels, freeMemory bool) error {
+	if err := c.requireFeature(ctx, FeatureFree); err != nil {
+		return err
+	}
+	return c.callAPI("/free", func(cl *api.Client) (*http.Response, error) {
+		return cl.PostFree(ctx, api.PostFreeJSONRequestBody{UnloadModels: unloadModels, FreeMemory: freeMemory})
+	})
+}
+
+// CheckAPIVersion 查询服务器版本，并返回各功能在该版本上是否可用
+func (c *Client) CheckAPIVersion(ctx context.Context) (string, map[string]bool, error) {
+	version, err := c.serverVersion(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	features := make(map[string]bool, len(featureMinVersions))
+	for feature := range featureMinVersions {
+		features[feature] = IsFeatureSupported(version, feature)
+	}
+	return version, features, nil
+}
@@ -0,0 +1,55 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// GenerateVideo 以 Client.Concurrency 为并发上限生成每个镜头的画面，下载后按镜头顺序用 ffmpeg 合成 fps 帧率的 MP4，
+// 写入 outputPath；需要 PATH 中有 ffmpeg。各帧并发生成，PreviousSceneURL 不会自动串联上一帧
+func (c *Client) GenerateVideo(ctx context.Context, scenes []SceneParams, fps int, outputPath string) error {
+	if len(scenes) == 0 {
+		return fmt.Errorf("comfyui video requires at least one scene")
+	}
+	if fps <= 0 {
+		return fmt.Errorf("comfyui video fps must be positive, got %d", fps)
+	}
+	params := make([]*Params, len(scenes))
+	for i := range scenes {
+		params[i] = &scenes[i].Params
+	}
+	results, err := c.GenerateBatch(ctx, params)
+	if err != nil {
+		return fmt.Errorf("comfyui video: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "comfyui-video-*")
+	if err != nil {
+		return fmt.Errorf("comfyui video: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	// results 与 scenes 顺序一致，帧文件名按镜头序号编号
+	for i, res := range results {
+		data, _, err := c.downloadImage(ctx, res.ImageURL)
+		if err != nil {
+			return fmt.Errorf("comfyui video frame %d: %w", i, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("frame_%05d.png", i)), data, 0o644); err != nil {
+			return fmt.Errorf("comfyui video frame %d: %w", i, err)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y",
+		"-framerate", strconv.Itoa(fps),
+		"-i", filepath.Join(dir, "frame_%05d.png"),
+		"-c:v", "libx264", "-pix_fmt", "yuv420p",
+		outputPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("comfyui video ffmpeg: %w: %s", err, out)
+	}
+	return nil
+}
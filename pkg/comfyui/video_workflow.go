@@ -0,0 +1,296 @@
+package comfyui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// VideoModel 视频生成使用的模型系列
+type VideoModel string
+
+const (
+	// VideoModelWan21 Wan2.1（内置节点），支持文生视频与图生视频
+	VideoModelWan21 VideoModel = "wan2.1"
+	// VideoModelSVD Stable Video Diffusion（内置节点），只支持图生视频
+	VideoModelSVD VideoModel = "svd"
+	// VideoModelAnimateDiff SD1.5 + AnimateDiff（需 ComfyUI-AnimateDiff-Evolved），只支持文生视频
+	VideoModelAnimateDiff VideoModel = "animatediff"
+)
+
+// VideoFormat 输出格式
+type VideoFormat string
+
+const (
+	// VideoFormatMP4 VHS_VideoCombine 输出 H.264 MP4（需 ComfyUI-VideoHelperSuite）
+	VideoFormatMP4 VideoFormat = "mp4"
+	// VideoFormatWebP 内置 SaveAnimatedWEBP 输出动图 WebP
+	VideoFormatWebP VideoFormat = "webp"
+)
+
+// 视频工作流使用的模型文件，与 ComfyUI 官方示例工作流一致，可按部署修改
+var (
+	WanT2VModel       = "wan2.1_t2v_1.3B_fp16.safetensors"
+	WanI2VModel       = "wan2.1_i2v_480p_14B_fp8_e4m3fn.safetensors"
+	WanTextEncoder    = "umt5_xxl_fp8_e4m3fn_scaled.safetensors"
+	WanVAE            = "wan_2.1_vae.safetensors"
+	WanClipVision     = "clip_vision_h.safetensors"
+	SVDCheckpoint     = "svd_xt.safetensors"
+	AnimateDiffBase   = "sd15\\v1-5-pruned-emaonly.safetensors"
+	AnimateDiffMotion = "mm_sd_v15_v2.ckpt"
+)
+
+const (
+	defaultVideoFrames = 33
+	defaultVideoFPS    = 16
+)
+
+// VideoParams 视频生成参数
+type VideoParams struct {
+	Prompt         string      `json:"prompt" yaml:"prompt"`
+	NegativePrompt string      `json:"negative_prompt,omitempty" yaml:"negative_prompt,omitempty"`
+	Model          VideoModel  `json:"model" yaml:"model"`   // 为空时为 wan2.1
+	Format         VideoFormat `json:"format" yaml:"format"` // 为空时为 mp4
+	// InputImage 首帧图片在 ComfyUI input 目录中的文件名（UploadImage 的返回值），非空时为图生视频
+	InputImage string `json:"input_image,omitempty" yaml:"input_image,omitempty"`
+	Width      int    `json:"width" yaml:"width"`   // 默认 832
+	Height     int    `json:"height" yaml:"height"` // 默认 480
+	Frames     int    `json:"frames" yaml:"frames"` // 帧数，默认 33（Wan 要求 4n+1）
+	FPS        int    `json:"fps" yaml:"fps"`       // 默认 16
+	// MotionStrength 运动幅度（0~1），SVD 映射为 motion_bucket_id，AnimateDiff 映射为 motion scale；Wan2.1 不支持，默认 0.5
+	MotionStrength float64 `json:"motion_strength,omitempty" yaml:"motion_strength,omitempty"`
+	Seed           int64   `json:"seed" yaml:"seed"` // 为 0 时随机
+	Steps          int     `json:"steps" yaml:"steps"`
+}
+
+func (p *VideoParams) applyDefaults() {
+	if p.Model == "" {
+		p.Model = VideoModelWan21
+	}
+	if p.Format == "" {
+		p.Format = VideoFormatMP4
+	}
+	if p.Width <= 0 {
+		p.Width = 832
+	}
+	if p.Height <= 0 {
+		p.Height = 480
+	}
+	if p.Frames <= 0 {
+		p.Frames = defaultVideoFrames
+	}
+	if p.FPS <= 0 {
+		p.FPS = defaultVideoFPS
+	}
+	if p.MotionStrength <= 0 || p.MotionStrength > 1 {
+		p.MotionStrength = 0.5
+	}
+	if p.Seed == 0 {
+		p.Seed = time.Now().UnixNano() % 100000000000000
+	}
+	if p.Steps <= 0 {
+		p.Steps = 20
+	}
+}
+
+// GenerateVideoClip 按 VideoParams 构建文生视频 / 图生视频工作流，等待完成并返回 MP4 / WebP 的 /view 地址。
+// 视频耗时较长，一般需要调大 Client.PollTimeout
+func (c *Client) GenerateVideoClip(ctx context.Context, p *VideoParams) (string, error) {
+	cp := *p
+	cp.applyDefaults()
+	workflow, err := buildVideoWorkflow(&cp)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := c.withAbort(ctx)
+	defer cancel()
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return "", c.localizeError(err)
+	}
+	entry, err := c.waitForEntry(ctx, promptID)
+	if err != nil {
+		c.cancelAbandoned(ctx, promptID)
+		return "", c.localizeError(c.abortErr(err))
+	}
+	files := entry.NodeVideos("")
+	if len(files) == 0 {
+		return "", fmt.Errorf("%w: prompt %s has no video", ErrNoOutput, promptID)
+	}
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return "", err
+	}
+	return historyImageURL(baseURL, files[0])
+}
+
+// buildVideoWorkflow 各模型的工作流结构参照 ComfyUI 官方示例
+func buildVideoWorkflow(p *VideoParams) (map[string]interface{}, error) {
+	b := NewWorkflowBuilder()
+	var images NodeRef
+	switch p.Model {
+	case VideoModelWan21:
+		images = buildWanVideo(b, p)
+	case VideoModelSVD:
+		if p.InputImage == "" {
+			return nil, fmt.Errorf("comfyui svd video requires an input image")
+		}
+		images = buildSVDVideo(b, p)
+	case VideoModelAnimateDiff:
+		if p.InputImage != "" {
+			return nil, fmt.Errorf("comfyui animatediff video does not support an input image")
+		}
+		images = buildAnimateDiffVideo(b, p)
+	default:
+		return nil, fmt.Errorf("comfyui unknown video model %q", p.Model)
+	}
+	switch p.Format {
+	case VideoFormatMP4:
+		b.AddNode("VHS_VideoCombine", map[string]interface{}{
+			"images":          images,
+			"frame_rate":      p.FPS,
+			"loop_count":      0,
+			"filename_prefix": "drama_video",
+			"format":          "video/h264-mp4",
+			"pingpong":        false,
+			"save_output":     true,
+		})
+	case VideoFormatWebP:
+		b.AddNode("SaveAnimatedWEBP", map[string]interface{}{
+			"images":          images,
+			"filename_prefix": "drama_video",
+			"fps":             float64(p.FPS),
+			"lossless":        false,
+			"quality":         90,
+			"method":          "default",
+		})
+	default:
+		return nil, fmt.Errorf("comfyui unknown video format %q", p.Format)
+	}
+	return b.Build(), nil
+}
+
+func buildWanVideo(b *WorkflowBuilder, p *VideoParams) NodeRef {
+	model := WanT2VModel
+	if p.InputImage != "" {
+		model = WanI2VModel
+	}
+	unet := b.AddNode("UNETLoader", map[string]interface{}{"unet_name": model, "weight_dtype": "default"})
+	clip := b.AddNode("CLIPLoader", map[string]interface{}{"clip_name": WanTextEncoder, "type": "wan"})
+	vae := b.AddNode("VAELoader", map[string]interface{}{"vae_name": WanVAE})
+	positive := b.AddNode("CLIPTextEncode", map[string]interface{}{"text": p.Prompt, "clip": b.Out(clip, 0)})
+	negative := b.AddNode("CLIPTextEncode", map[string]interface{}{"text": p.NegativePrompt, "clip": b.Out(clip, 0)})
+	pos, neg := b.Out(positive, 0), b.Out(negative, 0)
+	var latent NodeRef
+	if p.InputImage == "" {
+		latent = b.Out(b.AddNode("EmptyHunyuanLatentVideo", map[string]interface{}{
+			"width": p.Width, "height": p.Height, "length": p.Frames, "batch_size": 1,
+		}), 0)
+	} else {
+		image := b.AddNode("LoadImage", map[string]interface{}{"image": p.InputImage})
+		vision := b.AddNode("CLIPVisionLoader", map[string]interface{}{"clip_name": WanClipVision})
+		encoded := b.AddNode("CLIPVisionEncode", map[string]interface{}{
+			"clip_vision": b.Out(vision, 0), "image": b.Out(image, 0), "crop": "none",
+		})
+		i2v := b.AddNode("WanImageToVideo", map[string]interface{}{
+			"positive": pos, "negative": neg, "vae": b.Out(vae, 0),
+			"width": p.Width, "height": p.Height, "length": p.Frames, "batch_size": 1,
+			"clip_vision_output": b.Out(encoded, 0), "start_image": b.Out(image, 0),
+		})
+		pos, neg, latent = b.Out(i2v, 0), b.Out(i2v, 1), b.Out(i2v, 2)
+	}
+	sampling := b.AddNode("ModelSamplingSD3", map[string]interface{}{"model": b.Out(unet, 0), "shift": 8.0})
+	sampler := b.AddNode("KSampler", map[string]interface{}{
+		"model": b.Out(sampling, 0), "positive": pos, "negative": neg, "latent_image": latent,
+		"seed": p.Seed, "steps": p.Steps, "cfg": 6.0, "sampler_name": "uni_pc", "scheduler": "simple", "denoise": 1.0,
+	})
+	return b.Out(b.AddNode("VAEDecode", map[string]interface{}{"samples": b.Out(sampler, 0), "vae": b.Out(vae, 0)}), 0)
+}
+
+func buildSVDVideo(b *WorkflowBuilder, p *VideoParams) NodeRef {
+	ckpt := b.AddNode("ImageOnlyCheckpointLoader", map[string]interface{}{"ckpt_name": SVDCheckpoint})
+	image := b.AddNode("LoadImage", map[string]interface{}{"image": p.InputImage})
+	cond := b.AddNode("SVD_img2vid_Conditioning", map[string]interface{}{
+		"clip_vision": b.Out(ckpt, 1), "init_image": b.Out(image, 0), "vae": b.Out(ckpt, 2),
+		"width": p.Width, "height": p.Height, "video_frames": p.Frames,
+		"motion_bucket_id": int(p.MotionStrength * 255), "fps": p.FPS, "augmentation_level": 0.0,
+	})
+	guided := b.AddNode("VideoLinearCFGGuidance", map[string]interface{}{"model": b.Out(ckpt, 0), "min_cfg": 1.0})
+	sampler := b.AddNode("KSampler", map[string]interface{}{
+		"model": b.Out(guided, 0), "positive": b.Out(cond, 0), "negative": b.Out(cond, 1), "latent_image": b.Out(cond, 2),
+		"seed": p.Seed, "steps": p.Steps, "cfg": 2.5, "sampler_name": "euler", "scheduler": "karras", "denoise": 1.0,
+	})
+	return b.Out(b.AddNode("VAEDecode", map[string]interface{}{"samples": b.Out(sampler, 0), "vae": b.Out(ckpt, 2)}), 0)
+}
+
+func buildAnimateDiffVideo(b *WorkflowBuilder, p *VideoParams) NodeRef {
+	ckpt := b.AddNode("CheckpointLoaderSimple", map[string]interface{}{"ckpt_name": AnimateDiffBase})
+	// motion scale 1 为模型默认幅度，MotionStrength 0.5 对应 1
+	scale := b.AddNode("ADE_MultivalDynamic", map[string]interface{}{"float_val": p.MotionStrength * 2})
+	motion := b.AddNode("ADE_AnimateDiffLoaderGen1", map[string]interface{}{
+		"model": b.Out(ckpt, 0), "model_name": AnimateDiffMotion, "beta_schedule": "autoselect", "scale_multival": b.Out(scale, 0),
+	})
+	positive := b.AddNode("CLIPTextEncode", map[string]interface{}{"text": p.Prompt, "clip": b.Out(ckpt, 1)})
+	negative := b.AddNode("CLIPTextEncode", map[string]interface{}{"text": p.NegativePrompt, "clip": b.Out(ckpt, 1)})
+	latent := b.AddNode("EmptyLatentImage", map[string]interface{}{"width": p.Width, "height": p.Height, "batch_size": p.Frames})
+	sampler := b.AddNode("KSampler", map[string]interface{}{
+		"model": b.Out(motion, 0), "positive": b.Out(positive, 0), "negative": b.Out(negative, 0), "latent_image": b.Out(latent, 0),
+		"seed": p.Seed, "steps": p.Steps, "cfg": 7.0, "sampler_name": "euler_ancestral", "scheduler": "normal", "denoise": 1.0,
+	})
+	return b.Out(b.AddNode("VAEDecode", map[string]interface{}{"samples": b.Out(sampler, 0), "vae": b.Out(ckpt, 2)}), 0)
+}
+
+// defaultVideoPollTimeout VideoClient 等待视频生成的上限
+const defaultVideoPollTimeout = 30 * time.Minute
+
+// VideoClient 图生视频的简化入口（Wan2.1 图生视频，MP4 输出），供视频生成服务使用
+type VideoClient struct {
+	BaseURL string
+	// InputDir 非空时把首帧图片直接复制到 ComfyUI 的 input 目录（与 ComfyUI 同机部署、没有 /upload/image 时使用），否则上传
+	InputDir string
+	HTTP     *http.Client
+}
+
+// ImageToVideo 以本地图片 localPath 为首帧、prompt 为描述生成视频，返回视频的 /view 地址
+func (v *VideoClient) ImageToVideo(localPath, prompt string) (string, error) {
+	return v.ImageToVideoContext(context.Background(), localPath, prompt)
+}
+
+// ImageToVideoContext 同 ImageToVideo；ctx 结束时停止等待，并从服务器删除 / 中断该任务
+func (v *VideoClient) ImageToVideoContext(ctx context.Context, localPath, prompt string) (string, error) {
+	c := &Client{BaseURL: v.BaseURL, HTTP: v.HTTP, PollTimeout: defaultVideoPollTimeout, CancelOnContextDone: true}
+	var name string
+	var err error
+	if v.InputDir != "" {
+		name, err = copyToInputDir(localPath, v.InputDir)
+	} else {
+		name, err = c.uploadFile(ctx, localPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.GenerateVideoClip(ctx, &VideoParams{Prompt: prompt, InputImage: name})
+}
+
+// copyToInputDir 复制到 input 目录，文件名加 drama_ 前缀，返回 LoadImage 可用的文件名
+func copyToInputDir(localPath, inputDir string) (string, error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	name := "drama_" + filepath.Base(localPath)
+	dst, err := os.Create(filepath.Join(inputDir, name))
+	if err != nil {
+		return "", fmt.Errorf("comfyui copy to input dir: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("comfyui copy to input dir: %w", err)
+	}
+	return name, dst.Close()
+}
@@ -0,0 +1,103 @@
+package comfyui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultViewerMaxAge OutputViewerServer 对 output 图片设置的默认缓存时长
+const defaultViewerMaxAge = 24 * time.Hour
+
+// OutputViewerServer 把 GET /view?filename=...&subfolder=...&type=... 代理到 ComfyUI，
+// 便于放在 CDN 之后对外提供图片，而不直接暴露 ComfyUI 实例
+type OutputViewerServer struct {
+	client *Client
+	// MaxAge output 图片的 Cache-Control max-age，默认 24h；temp 图片会被 ComfyUI 清理，始终为 no-store
+	MaxAge time.Duration
+}
+
+func NewOutputViewerServer(c *Client) *OutputViewerServer {
+	return &OutputViewerServer{client: c, MaxAge: defaultViewerMaxAge}
+}
+
+func (s *OutputViewerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/view" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	filename := q.Get("filename")
+	if filename == "" || strings.Contains(filename, "..") || strings.Contains(q.Get("subfolder"), "..") {
+		http.Error(w, "invalid filename", http.StatusBadRequest)
+		return
+	}
+	imageType := ImageTypeOutput
+	if t := q.Get("type"); t != "" {
+		var err error
+		if imageType, err = ParseImageType(t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	baseURL, err := s.client.baseURL()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, ImageURL(baseURL, filename, q.Get("subfolder"), imageType), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp, err := s.client.do(req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("comfyui view: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		status := http.StatusBadGateway
+		if resp.StatusCode == http.StatusNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, "comfyui view "+resp.Status, status)
+		return
+	}
+
+	body := bufio.NewReaderSize(resp.Body, 512)
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") && !strings.HasPrefix(contentType, "video/") {
+		// 部分版本对 webp / 自定义扩展名返回 application/octet-stream，按内容识别
+		head, _ := body.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", s.cacheControl(imageType))
+	if n := resp.Header.Get("Content-Length"); n != "" {
+		w.Header().Set("Content-Length", n)
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, body)
+}
+
+func (s *OutputViewerServer) cacheControl(imageType ImageType) string {
+	if imageType == ImageTypeTemp {
+		return "no-store"
+	}
+	maxAge := s.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultViewerMaxAge
+	}
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
@@ -0,0 +1,248 @@
+package comfyui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// ProgressEvent ComfyUI 通过 /ws 推送的执行事件
+type ProgressEvent struct {
+	Type     string // status / execution_start / progress / executing / executed
+	PromptID string
+	Step     int    // 仅 progress 事件：当前步数
+	MaxStep  int    // 仅 progress 事件：总步数
+	Node     string // progress / executing / executed 事件：当前节点 ID
+	// 仅 status 事件（开始执行前）：服务器剩余任务数，以及本任务前面还有多少个任务（见 QueuePosition）
+	QueueRemaining int
+	QueuePosition  int
+}
+
+// wsMessage /ws 推送的 JSON 消息
+type wsMessage struct {
+	Type string `json:"type"`
+	Data struct {
+		PromptID string  `json:"prompt_id"`
+		Node     *string `json:"node"`
+		Value    int     `json:"value"`
+		Max      int     `json:"max"`
+		Output   struct {
+			Images []HistoryImage `json:"images"`
+		} `json:"output"`
+		NodeID           string   `json:"node_id"`
+		NodeType         string   `json:"node_type"`
+		ExceptionType    string   `json:"exception_type"`
+		ExceptionMessage string   `json:"exception_message"`
+		Executed         []string `json:"executed"`
+		Traceback        []string `json:"traceback"`
+		Status           struct {
+			ExecInfo struct {
+				QueueRemaining int `json:"queue_remaining"`
+			} `json:"exec_info"`
+		} `json:"status"`
+	} `json:"data"`
+}
+
+// GenerateWithProgress 提交工作流并通过 /ws 接收实时进度，每个事件回调 cb（可为 nil），返回图片 URL；
+// 服务器不支持 WebSocket 时退化为轮询 /history
+func (c *Client) GenerateWithProgress(ctx context.Context, p *Params, cb func(event ProgressEvent)) (string, error) {
+	return c.generate(ctx, p, nil, cb)
+}
+
+// execute 提交工作流并返回第一张输出图片
+func (c *Client) execute(ctx context.Context, workflow map[string]interface{}, cb func(event ProgressEvent)) (string, error) {
+	urls, err := c.executeWorkflow(ctx, workflow, "", cb)
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+// executeWorkflow 提交工作流并等待 outputNodeID 的输出图片：服务器支持时用 WebSocket，否则轮询
+func (c *Client) executeWorkflow(ctx context.Context, workflow map[string]interface{}, outputNodeID string, cb func(event ProgressEvent)) ([]string, error) {
+	if !c.webSocketAvailable(ctx) {
+		return c.pollWorkflow(ctx, workflow, outputNodeID)
+	}
+	// 每次生成使用独立的 client_id：ComfyUI 按 client_id 保存连接，同 ID 的新连接会顶掉旧连接
+	clientID := c.clientID(ctx) + "_" + uuid.NewString()
+	conn, err := c.dialWebSocket(ctx, clientID)
+	if err != nil {
+		c.warnw("ComfyUI websocket connect failed, falling back to polling", "error", err)
+		return c.pollWorkflow(ctx, workflow, outputNodeID)
+	}
+	defer conn.Close()
+
+	// 先建立连接再提交，避免执行很快时错过事件
+	promptID, err := c.submitPrompt(ctx, workflow, clientID)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := c.waitForImagesWS(ctx, conn, promptID, outputNodeID, cb)
+	if err != nil {
+		c.cancelAbandoned(ctx, promptID)
+	}
+	return urls, err
+}
+
+func (c *Client) pollWorkflow(ctx context.Context, workflow map[string]interface{}, outputNodeID string) ([]string, error) {
+	promptID, err := c.submitWorkflow(ctx, workflow)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := c.waitForImages(ctx, promptID, outputNodeID)
+	if err != nil {
+		c.cancelAbandoned(ctx, promptID)
+	}
+	return urls, err
+}
+
+// webSocketAvailable 首次调用时探测 /ws 是否可连接并记住结果；旧版 ComfyUI 或不转发 WebSocket 的代理会退化为轮询
+func (c *Client) webSocketAvailable(ctx context.Context) bool {
+	if !c.UseWebSocketIfAvailable || c.requireFeature(ctx, FeatureWebSocket) != nil {
+		return false
+	}
+	c.wsProbeOnce.Do(func() {
+		conn, err := c.dialWebSocket(ctx, c.clientID(ctx)+"_probe_"+uuid.NewString())
+		if err != nil {
+			c.warnw("ComfyUI websocket unavailable, using HTTP polling", "error", err)
+			return
+		}
+		conn.Close()
+		c.wsAvailable = true
+	})
+	return c.wsAvailable
+}
+
+func (c *Client) dialWebSocket(ctx context.Context, clientID string) (*websocket.Conn, error) {
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	wsURL := "ws" + strings.TrimPrefix(baseURL, "http") + "/ws?clientId=" + url.QueryEscape(clientID)
+	config, err := websocket.NewConfig(wsURL, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return config.DialContext(ctx)
+}
+
+// waitForImagesWS 读取 /ws 消息直到本次 prompt 的输出节点产出图片或出错
+func (c *Client) waitForImagesWS(ctx context.Context, conn *websocket.Conn, promptID, outputNodeID string, cb func(event ProgressEvent)) ([]string, error) {
+	start := time.Now()
+	timeout := c.pollTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// Receive 不感知 ctx，ctx 结束时关闭连接使其返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	emit := func(e ProgressEvent) {
+		if cb != nil {
+			cb(e)
+		}
+		if c.OnProgressEvent != nil {
+			c.OnProgressEvent(e)
+		}
+	}
+	step := 0
+	started := false
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && time.Since(start) >= timeout {
+				return nil, &PollingTimeoutError{PromptID: promptID}
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("comfyui websocket: %w", err)
+		}
+		// 每次生成使用独立的 client_id，连接上的预览帧都属于当前任务
+		if frame, ok := parsePreviewFrame(raw); ok {
+			if c.RecordStepPreviews {
+				c.previews.add(promptID, step, frame)
+			}
+			continue
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		// status 是广播消息，不带 prompt_id；开始执行前据此报告排队位置
+		if msg.Type == "status" {
+			if !started {
+				position, _, err := c.QueuePosition(ctx, promptID)
+				if err != nil {
+					c.warnw("ComfyUI queue position lookup failed", "error", err)
+					continue
+				}
+				emit(ProgressEvent{Type: msg.Type, PromptID: promptID, QueueRemaining: msg.Data.Status.ExecInfo.QueueRemaining, QueuePosition: position})
+			}
+			continue
+		}
+		if msg.Data.PromptID != promptID {
+			// 其它任务的消息
+			continue
+		}
+		node := ""
+		if msg.Data.Node != nil {
+			node = *msg.Data.Node
+		}
+		switch msg.Type {
+		case "execution_start":
+			started = true
+			emit(ProgressEvent{Type: msg.Type, PromptID: promptID})
+		case "progress":
+			step = msg.Data.Value
+			emit(ProgressEvent{Type: msg.Type, PromptID: promptID, Step: msg.Data.Value, MaxStep: msg.Data.Max, Node: node})
+			if c.OnProgress != nil && msg.Data.Max > 0 && msg.Data.Value < msg.Data.Max {
+				c.OnProgress(msg.Data.Value*progressTotal/msg.Data.Max, progressTotal)
+			}
+		case "execution_error":
+			return nil, &ExecutionError{
+				PromptID: promptID, NodeID: msg.Data.NodeID, NodeType: msg.Data.NodeType,
+				ExceptionType: msg.Data.ExceptionType, ExceptionMessage: msg.Data.ExceptionMessage, Executed: msg.Data.Executed,
+				Traceback: msg.Data.Traceback,
+			}
+		case "execution_interrupted":
+			return nil, &ExecutionError{
+				PromptID: promptID, NodeID: msg.Data.NodeID, NodeType: msg.Data.NodeType, Executed: msg.Data.Executed, Interrupted: true,
+			}
+		case "executed":
+			emit(ProgressEvent{Type: msg.Type, PromptID: promptID, Node: node})
+			images := msg.Data.Output.Images
+			// 取全部输出时等到执行结束（executing 的 node 为 null）再从 history 读取
+			if len(images) == 0 || outputNodeID == allOutputNodes || (outputNodeID != "" && (msg.Data.Node == nil || *msg.Data.Node != outputNodeID)) {
+				continue
+			}
+			return c.finishImages(ctx, promptID, node, images, start)
+		case "executing":
+			if msg.Data.Node != nil {
+				emit(ProgressEvent{Type: msg.Type, PromptID: promptID, Node: node})
+				continue
+			}
+			// node 为 null 表示执行结束；输出节点命中缓存时不会推送 executed，从 history 取结果
+			entry, err := c.GetHistory(ctx, promptID)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				return nil, fmt.Errorf("%w: prompt %s has no history", ErrNoOutput, promptID)
+			}
+			if execErr := entry.Status.ExecutionError(); execErr != nil {
+				return nil, execErr
+			}
+			if len(entry.NodeErrors) > 0 {
+				return nil, &NodeExecutionError{NodeErrors: entry.NodeErrors}
+			}
+			return c.entryImages(ctx, promptID, entry, outputNodeID, start)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package comfyui
+
+// flux.json 中使用的默认模型文件
+const (
+	DefaultUNETModel  = "flux\\flux1-dev.safetensors"
+	DefaultT5Model    = "flux\\t5xxl_fp8_e4m3fn.safetensors"
+	DefaultCLIPLModel = "flux\\clip_l.safetensors"
+	DefaultVAEModel   = "flux\\ae.safetensors"
+)
+
+// buildWorkflow 与 flux.json 一致：含 BaiduTranslateNode(24) -> CLIPTextEncode(21)，其余为 Flux 文生图
+func (c *Client) buildWorkflow(p *Params) map[string]interface{} {
+	// 节点 24：BaiduTranslateNode，输入为 prompt（中译英等），输出给 21
+	inputs24 := map[string]interface{}{
+		"from_translate": "auto",
+		"to_translate":   "en",
+		"text":           p.Prompt,
+	}
+	if p.BaiduTranslateAppID != "" && p.BaiduTranslateAppKey != "" {
+		inputs24["baidu_appid"] = p.BaiduTranslateAppID
+		inputs24["baidu_appkey"] = p.BaiduTranslateAppKey
+	}
+	node24 := map[string]interface{}{
+		"inputs":     inputs24,
+		"class_type": "BaiduTranslateNode",
+	}
+
+	unetName := p.UNETModelName
+	if unetName == "" {
+		unetName = DefaultUNETModel
+	}
+	filenamePrefix := c.filenamePrefix(p)
+
+	workflow := map[string]interface{}{
+		"4": map[string]interface{}{
+			"inputs":     map[string]interface{}{"conditioning": []interface{}{"21", 0}},
+			"class_type": "ConditioningZeroOut",
+		},
+		"5": map[string]interface{}{
+			"inputs":     map[string]interface{}{"samples": []interface{}{"15", 0}, "vae": []interface{}{"19", 0}},
+			"class_type": "VAEDecode",
+		},
+		"8": map[string]interface{}{
+			"inputs":     map[string]interface{}{"filename_prefix": filenamePrefix, "images": []interface{}{"5", 0}},
+			"class_type": "SaveImage",
+		},
+		"15": map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"seed": p.Seed, "steps": p.Steps, "cfg": p.CFG,
+				"sampler_name": p.Sampler, "scheduler": p.Scheduler, "denoise": p.Denoise,
+				"model": []interface{}{"17", 0}, "positive": []interface{}{"21", 0},
+				"negative": []interface{}{"4", 0}, "latent_image": []interface{}{"20", 0},
+			},
+			"class_type": "KSampler",
+		},
+		"17": map[string]interface{}{
+			"inputs":     map[string]interface{}{"unet_name": unetName, "weight_dtype": "fp8_e4m3fn"},
+			"class_type": "UNETLoader",
+		},
+		"18": map[string]interface{}{
+			"inputs": map[string]interface{}{
+				"clip_name1": DefaultT5Model,
+				"clip_name2": DefaultCLIPLModel,
+				"type":       "flux", "device": "default",
+			},
+			"class_type": "DualCLIPLoader",
+		},
+		"19": map[string]interface{}{
+			"inputs":     map[string]interface{}{"vae_name": DefaultVAEModel},
+			"class_type": "VAELoader",
+		},
+		"20": map[string]interface{}{
+			"inputs":     map[string]interface{}{"width": p.Width, "height": p.Height, "batch_size": max(1, p.BatchSize)},
+			"class_type": "EmptyLatentImage",
+		},
+		"21": map[string]interface{}{
+			"inputs":     map[string]interface{}{"text": []interface{}{"24", 0}, "clip": []interface{}{"18", 0}},
+			"class_type": "CLIPTextEncode",
+		},
+		"24": node24,
+	}
+	if p.NegativePrompt != "" {
+		addNegativePrompt(workflow, p.NegativePrompt)
+	}
+	if len(p.UNETShards) > 0 {
+		applyUNETShards(workflow, p.UNETShards)
+	}
+	if len(p.LoRAs) > 0 {
+		addLoRAs(workflow, p.LoRAs)
+	}
+	if p.AdvancedSampler != nil {
+		applyAdvancedSampler(workflow, p)
+	}
+	if p.SafetyCheck {
+		addSafetyChecker(workflow)
+	}
+	if len(p.Metadata) > 0 {
+		addMetadataNode(workflow, p.Metadata)
+	}
+	return workflow
+}
+
+// negativePromptNodeID 负向提示词的 CLIPTextEncode 节点，替换 ConditioningZeroOut(4) 作为 KSampler 的 negative
+const negativePromptNodeID = "25"
+
+func addNegativePrompt(workflow map[string]interface{}, text string) {
+	workflow[negativePromptNodeID] = map[string]interface{}{
+		"inputs":     map[string]interface{}{"text": text, "clip": []interface{}{"18", 0}},
+		"class_type": "CLIPTextEncode",
+	}
+	delete(workflow, "4")
+	workflow["15"].(map[string]interface{})["inputs"].(map[string]interface{})["negative"] = []interface{}{negativePromptNodeID, 0}
+}
@@ -0,0 +1,201 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownTemplate Params.Template 指定的工作流模板未注册
+var ErrUnknownTemplate = errors.New("comfyui unknown workflow template")
+
+// ErrTemplateUnsupported 使用模板（Template / WorkflowOverride / UseDefaultWorkflow）时设置了只能作用于内置工作流的参数
+var ErrTemplateUnsupported = errors.New("comfyui parameter not supported with workflow templates")
+
+// DefaultTemplateName 内置 Flux 模板的名称，Params.Template 为该值且未注册同名模板时使用 DefaultWorkflowTemplate
+const DefaultTemplateName = "flux"
+
+// WorkflowTemplate 从 ComfyUI 导出的 API 格式工作流（Save (API Format)），Bindings 声明 Params 注入到哪些节点输入，
+// 从而无需改代码即可换用 SDXL、自定义 Flux 等任意工作流
+type WorkflowTemplate struct {
+	Name     string
+	Workflow map[string]interface{}
+	// Bindings 参数名到 "节点ID.输入名" 列表，如 {"prompt": ["6.text"], "seed": ["3.seed"]}；可用参数见 TemplateParamNames，
+	// 另可绑定 Params.TemplateVars 中的任意键（如 ckpt_name）
+	Bindings map[string][]string
+}
+
+// TemplateConfig WorkflowConfig 中一个命名模板：File 为 API 格式工作流 JSON 的路径（相对路径相对于配置文件所在目录）
+type TemplateConfig struct {
+	File     string              `json:"file" yaml:"file"`
+	Bindings map[string][]string `json:"bindings" yaml:"bindings"`
+}
+
+// templateParams 可绑定的内置参数；值为空（字符串为空、Denoise 为 0 等）时保留模板中的原值
+var templateParams = map[string]func(p *Params) interface{}{
+	"prompt":          func(p *Params) interface{} { return p.Prompt },
+	"negative_prompt": func(p *Params) interface{} { return p.NegativePrompt },
+	"batch_size":      func(p *Params) interface{} { return max(1, p.BatchSize) },
+	"width":           func(p *Params) interface{} { return p.Width },
+	"height":          func(p *Params) interface{} { return p.Height },
+	"seed":            func(p *Params) interface{} { return p.Seed },
+	"steps":           func(p *Params) interface{} { return p.Steps },
+	"cfg":             func(p *Params) interface{} { return p.CFG },
+	"sampler":         func(p *Params) interface{} { return p.Sampler },
+	"scheduler":       func(p *Params) interface{} { return p.Scheduler },
+	"denoise":         func(p *Params) interface{} { return p.Denoise },
+	"unet_model":      func(p *Params) interface{} { return p.UNETModelName },
+}
+
+// TemplateParamNames 返回 Bindings 中可用的内置参数名（已排序）
+func TemplateParamNames() []string {
+	names := make([]string, 0, len(templateParams))
+	for name := range templateParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseWorkflowTemplate 解析 API 格式工作流 JSON 并校验 bindings 指向的节点与输入都存在
+func ParseWorkflowTemplate(name string, data []byte, bindings map[string][]string) (*WorkflowTemplate, error) {
+	var wf map[string]interface{}
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("comfyui parse workflow template %s: %w", name, err)
+	}
+	t := &WorkflowTemplate{Name: name, Workflow: wf, Bindings: bindings}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadWorkflowTemplate 从文件读取 API 格式工作流作为模板
+func LoadWorkflowTemplate(name, path string, bindings map[string][]string) (*WorkflowTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWorkflowTemplate(name, data, bindings)
+}
+
+// DefaultWorkflowTemplate 内置的 defaults/flux_workflow.json，绑定与 buildWorkflow 的节点 ID 一致
+func DefaultWorkflowTemplate() (*WorkflowTemplate, error) {
+	return ParseWorkflowTemplate(DefaultTemplateName, defaultWorkflowJSON, map[string][]string{
+		"prompt":     {"24.text"},
+		"width":      {"20.width"},
+		"height":     {"20.height"},
+		"seed":       {"15.seed"},
+		"steps":      {"15.steps"},
+		"cfg":        {"15.cfg"},
+		"sampler":    {"15.sampler_name"},
+		"scheduler":  {"15.scheduler"},
+		"denoise":    {"15.denoise"},
+		"unet_model": {"17.unet_name"},
+	})
+}
+
+func (t *WorkflowTemplate) validate() error {
+	for param, targets := range t.Bindings {
+		for _, target := range targets {
+			nodeID, input, ok := strings.Cut(target, ".")
+			if !ok || nodeID == "" || input == "" {
+				return fmt.Errorf("comfyui workflow template %s: binding %s target %q is not \"node.input\"", t.Name, param, target)
+			}
+			node, _ := t.Workflow[nodeID].(map[string]interface{})
+			if node == nil {
+				return fmt.Errorf("comfyui workflow template %s: binding %s: node %s not found", t.Name, param, nodeID)
+			}
+			if _, ok := node["inputs"].(map[string]interface{})[input]; !ok {
+				return fmt.Errorf("comfyui workflow template %s: binding %s: node %s has no input %s", t.Name, param, nodeID, input)
+			}
+		}
+	}
+	return nil
+}
+
+// Render 返回注入 p 之后的工作流副本：先替换字符串中的 {{name}} 占位符，再按 Bindings 覆盖节点输入
+func (t *WorkflowTemplate) Render(p *Params) map[string]interface{} {
+	wf := substituteWorkflowVars(t.Workflow, workflowVariables(p)).(map[string]interface{})
+	for param, targets := range t.Bindings {
+		value, ok := templateValue(p, param)
+		if !ok {
+			continue
+		}
+		for _, target := range targets {
+			nodeID, input, _ := strings.Cut(target, ".")
+			if node, _ := wf[nodeID].(map[string]interface{}); node != nil {
+				if inputs, _ := node["inputs"].(map[string]interface{}); inputs != nil {
+					inputs[input] = value
+				}
+			}
+		}
+	}
+	return wf
+}
+
+// templateValue 参数 name 的值，Params.TemplateVars 优先；值为空时返回 false
+func templateValue(p *Params, name string) (interface{}, bool) {
+	if v, ok := p.TemplateVars[name]; ok {
+		return v, true
+	}
+	get, ok := templateParams[name]
+	if !ok {
+		return nil, false
+	}
+	v := get(p)
+	switch val := v.(type) {
+	case string:
+		return v, val != ""
+	case float64:
+		return v, val != 0
+	}
+	return v, true
+}
+
+// workflowTemplate 按名称查找已注册的模板，DefaultTemplateName 未注册时为内置模板
+func (c *Client) workflowTemplate(name string) (*WorkflowTemplate, error) {
+	if t, ok := c.templates[name]; ok {
+		return t, nil
+	}
+	if name == DefaultTemplateName {
+		return DefaultWorkflowTemplate()
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+}
+
+// renderWorkflow Params.Template 非空时渲染对应模板，否则回退到 WorkflowOverride / UseDefaultWorkflow
+func (c *Client) renderWorkflow(p *Params) (map[string]interface{}, error) {
+	if p.Template == "" {
+		return templateWorkflow(p)
+	}
+	t, err := c.workflowTemplate(p.Template)
+	if err != nil {
+		return nil, err
+	}
+	if c.ModelAliases != nil && startsWithLetter(p.UNETModelName) {
+		p.UNETModelName = c.ModelAliases.ResolveModelName(p.UNETModelName)
+	}
+	return t.Render(p), nil
+}
+
+// loadTemplates 加载配置中的模板，相对路径相对于 dir
+func loadTemplates(dir string, configs map[string]TemplateConfig) (map[string]*WorkflowTemplate, error) {
+	templates := make(map[string]*WorkflowTemplate, len(configs))
+	for name, tc := range configs {
+		path := tc.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		t, err := LoadWorkflowTemplate(name, path, tc.Bindings)
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
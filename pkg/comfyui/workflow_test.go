@@ -0,0 +1,195 @@
+package comfyui
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWorkflowDeterminism 相同参数多次构建的工作流校验和必须一致，避免 map 遍历顺序等因素影响复现
+func TestWorkflowDeterminism(t *testing.T) {
+	c := &Client{}
+	params := func() *Params {
+		p := &Params{Prompt: "古风少女站在桥上", Width: 1024, Height: 576, Seed: 42, SafetyCheck: true,
+			Metadata: map[string]string{"episode": "3", "scene": "12", "character": "lin"}}
+		p.SamplerConfig = SamplerQuality
+		return p
+	}
+
+	want, err := WorkflowChecksum(c.buildWorkflow(params()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		got, err := WorkflowChecksum(c.buildWorkflow(params()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("iteration %d: checksum %s, want %s", i, got, want)
+		}
+	}
+}
+
+// TestDefaultWorkflowMatchesBuilder 内置模板代入变量后应与 buildWorkflow 的默认工作流一致
+func TestDefaultWorkflowMatchesBuilder(t *testing.T) {
+	p := &Params{Prompt: "雨夜街头的侦探", Width: 1024, Height: 576, Seed: 42}
+	applyDefaults(p)
+
+	tmpl, err := templateWorkflow(&Params{UseDefaultWorkflow: true, Prompt: p.Prompt, Width: p.Width, Height: p.Height, Seed: p.Seed, SamplerConfig: p.SamplerConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := WorkflowChecksum(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := WorkflowChecksum((&Client{}).buildWorkflow(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("default workflow checksum %s, want %s", got, want)
+	}
+}
+
+// TestDefaultTemplateBindings 内置模板按 Bindings 注入采样参数后应与 buildWorkflow 一致
+func TestDefaultTemplateBindings(t *testing.T) {
+	p := &Params{Prompt: "雨夜街头的侦探", Width: 768, Height: 1344, Seed: 7, SamplerPreset: "quality"}
+	applyDefaults(p)
+
+	tmpl, err := DefaultWorkflowTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := WorkflowChecksum(tmpl.Render(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := WorkflowChecksum((&Client{}).buildWorkflow(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("rendered template checksum %s, want %s", got, want)
+	}
+}
+
+// backendNodeClasses ComfyUI 后端 NODE_CLASS_MAPPINGS 中存在的节点（内置节点 + 部署中安装的 BaiduTranslateNode）；
+// Note 等只存在于前端的节点不在其中，提交时会被 validate_prompt 拒绝
+var backendNodeClasses = map[string]bool{
+	"ConditioningZeroOut": true, "VAEDecode": true, "SaveImage": true, "KSampler": true, "UNETLoader": true,
+	"DualCLIPLoader": true, "VAELoader": true, "EmptyLatentImage": true, "CLIPTextEncode": true,
+	"PrimitiveString": true, "LoraLoaderModelOnly": true, "BaiduTranslateNode": true,
+}
+
+// TestMetadataNodeIsBackendNode 带 Metadata 的工作流只能包含后端存在的节点，且 Metadata 能从 history 读回
+func TestMetadataNodeIsBackendNode(t *testing.T) {
+	metadata := map[string]string{"project_id": "p1", "scene": "12"}
+	wf := (&Client{}).buildWorkflow(&Params{Prompt: "雨夜", Width: 1024, Height: 576, Seed: 1, Metadata: metadata})
+	for id, node := range wf {
+		classType := node.(map[string]interface{})["class_type"].(string)
+		if !backendNodeClasses[classType] {
+			t.Errorf("node %s class_type %q is not a backend node", id, classType)
+		}
+	}
+	history := map[string]interface{}{"prompt": []interface{}{1, "id", wf, map[string]interface{}{}, []interface{}{}}}
+	if got := parseHistoryMetadata(history); got["project_id"] != "p1" || got["scene"] != "12" {
+		t.Errorf("parseHistoryMetadata = %v, want %v", got, metadata)
+	}
+}
+
+// TestAdvancedSamplerPatches SamplerCustomAdvanced 下 IP-Adapter 与 ControlNet 仍接入 guider，LoRA 链不被丢弃
+func TestAdvancedSamplerPatches(t *testing.T) {
+	p := &Params{Prompt: "侠客", Width: 1024, Height: 576, Seed: 1, AdvancedSampler: &AdvancedSamplerConfig{},
+		LoRAs: []LoRA{{Name: "hero.safetensors", Strength: 0.8}}}
+	applyDefaults(p)
+	wf := (&Client{}).buildWorkflow(p)
+	if class := wf["53"].(map[string]interface{})["class_type"]; class != "BasicScheduler" {
+		t.Fatalf("default sigmas node = %v, want BasicScheduler", class)
+	}
+	if err := insertIPAdapter(wf, "ref.png", 0.6); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertControlNet(wf, "edge.png", "", 0.6); err != nil {
+		t.Fatal(err)
+	}
+	input := func(id, name string) interface{} {
+		return wf[id].(map[string]interface{})["inputs"].(map[string]interface{})[name]
+	}
+	if got := input("32", "model"); got.([]interface{})[0] != "70" {
+		t.Errorf("IP-Adapter model = %v, want LoRA node 70", got)
+	}
+	if got := input("51", "model"); got.([]interface{})[0] != "32" {
+		t.Errorf("guider model = %v, want IP-Adapter node 32", got)
+	}
+	if input(controlNetApplyNodeID, "positive") == nil || input(controlNetApplyNodeID, "negative") == nil {
+		t.Errorf("ControlNet conditioning not wired: %v", wf[controlNetApplyNodeID])
+	}
+	if got := input("51", "conditioning"); got.([]interface{})[0] != controlNetApplyNodeID {
+		t.Errorf("guider conditioning = %v, want ControlNet node", got)
+	}
+
+	p.AdvancedSampler = &AdvancedSamplerConfig{CFGGuider: NodeRef{NodeID: "99"}}
+	custom := (&Client{}).buildWorkflow(p)
+	if err := insertIPAdapter(custom, "ref.png", 0.6); !errors.Is(err, ErrAdvancedSamplerConflict) {
+		t.Errorf("custom guider err = %v, want ErrAdvancedSamplerConflict", err)
+	}
+}
+
+// TestValidateWorkflow dry run 对照 /object_info 报告未注册节点、缺失输入、断开的连线与非法下拉值
+func TestValidateWorkflow(t *testing.T) {
+	var info map[string]nodeInfo
+	if err := json.Unmarshal([]byte(`{
+		"KSampler": {"input": {"required": {
+			"model": ["MODEL"], "seed": ["INT", {"default": 0}],
+			"sampler_name": [["euler", "dpmpp_2m"], {}], "scheduler": ["COMBO", {"options": ["normal", "karras"]}]
+		}}},
+		"UNETLoader": {"input": {"required": {"unet_name": [["flux1-dev.safetensors"], {}]}}}
+	}`), &info); err != nil {
+		t.Fatal(err)
+	}
+	workflow := map[string]interface{}{
+		"1": map[string]interface{}{"class_type": "UNETLoader", "inputs": map[string]interface{}{"unet_name": "flux1-dev.safetensors"}},
+		"2": map[string]interface{}{"class_type": "KSampler", "inputs": map[string]interface{}{
+			"model": []interface{}{"1", 0}, "seed": 1, "sampler_name": "euler", "scheduler": "normal",
+		}},
+	}
+	if errs := validateWorkflow(workflow, info); len(errs) != 0 {
+		t.Fatalf("valid workflow reported %v", errs)
+	}
+
+	workflow["2"].(map[string]interface{})["inputs"] = map[string]interface{}{
+		"model": []interface{}{"9", 0}, "sampler_name": "unknown", "scheduler": "karras",
+	}
+	workflow["3"] = map[string]interface{}{"class_type": "FrontendOnlyNode", "inputs": map[string]interface{}{}}
+	want := []DryRunError{
+		{NodeID: "2", ClassType: "KSampler", Message: `missing required input "seed"`},
+		{NodeID: "2", ClassType: "KSampler", Message: `input "model" links to missing node 9`},
+		{NodeID: "2", ClassType: "KSampler", Message: `value unknown for input "sampler_name" not in list`},
+		{NodeID: "3", ClassType: "FrontendOnlyNode", Message: "node type FrontendOnlyNode not available on server"},
+	}
+	errs := validateWorkflow(workflow, info)
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want %v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("errors[%d] = %+v, want %+v", i, errs[i], want[i])
+		}
+	}
+}
+
+// TestTimestampFilenamePortable FilenameTimestamp 生成的文件名不含 : 与 +，可直接用作 Windows 与对象存储的文件名
+func TestTimestampFilenamePortable(t *testing.T) {
+	c := &Client{FilenameStrategy: FilenameTimestamp}
+	name := c.filenameBase(&Params{})
+	if strings.ContainsAny(name, ":+") {
+		t.Fatalf("filename %q contains : or +", name)
+	}
+	if _, err := time.Parse(timestampFilenameLayout, name); err != nil {
+		t.Fatalf("filename %q: %v", name, err)
+	}
+}